@@ -13,12 +13,24 @@ import (
 type Configuration struct {
 	ResultBuffer       uint `yaml:"result-buffer"`
 	HTTP               http.Configuration
-	HealthchecksLabels []string                                      `yaml:"healthchecks-labels"`
-	CommandChecks      []healthcheck.CommandHealthcheckConfiguration `yaml:"command-checks"`
-	DNSChecks          []healthcheck.DNSHealthcheckConfiguration     `yaml:"dns-checks"`
-	TCPChecks          []healthcheck.TCPHealthcheckConfiguration     `yaml:"tcp-checks"`
-	HTTPChecks         []healthcheck.HTTPHealthcheckConfiguration    `yaml:"http-checks"`
-	TLSChecks          []healthcheck.TLSHealthcheckConfiguration     `yaml:"tls-checks"`
+	HealthchecksLabels []string                                         `yaml:"healthchecks-labels"`
+	CommandChecks      []healthcheck.CommandHealthcheckConfiguration    `yaml:"command-checks"`
+	DNSChecks          []healthcheck.DNSHealthcheckConfiguration        `yaml:"dns-checks"`
+	TCPChecks          []healthcheck.TCPHealthcheckConfiguration        `yaml:"tcp-checks"`
+	HTTPChecks         []healthcheck.HTTPHealthcheckConfiguration       `yaml:"http-checks"`
+	TLSChecks          []healthcheck.TLSHealthcheckConfiguration        `yaml:"tls-checks"`
+	FileChecks         []healthcheck.FileHealthcheckConfiguration       `yaml:"file-checks"`
+	ProcessChecks      []healthcheck.ProcessHealthcheckConfiguration    `yaml:"process-checks"`
+	HostChecks         []healthcheck.HostHealthcheckConfiguration       `yaml:"host-checks"`
+	PrometheusChecks   []healthcheck.PrometheusHealthcheckConfiguration `yaml:"prometheus-checks"`
+	WebSocketChecks    []healthcheck.WebSocketHealthcheckConfiguration  `yaml:"websocket-checks"`
+	MQTTChecks         []healthcheck.MQTTHealthcheckConfiguration       `yaml:"mqtt-checks"`
+	LDAPChecks         []healthcheck.LDAPHealthcheckConfiguration       `yaml:"ldap-checks"`
+	SSHChecks          []healthcheck.SSHHealthcheckConfiguration        `yaml:"ssh-checks"`
+	NTPChecks          []healthcheck.NTPHealthcheckConfiguration        `yaml:"ntp-checks"`
+	SNMPChecks         []healthcheck.SNMPHealthcheckConfiguration       `yaml:"snmp-checks"`
+	DockerChecks       []healthcheck.DockerHealthcheckConfiguration     `yaml:"docker-checks"`
+	Silences           []healthcheck.RecurringSilence                   `yaml:"silences"`
 	Exporters          exporter.Configuration
 	Discovery          discovery.Configuration
 }
@@ -69,6 +81,90 @@ func (configuration *Configuration) UnmarshalYAML(unmarshal func(interface{}) er
 			return errors.Wrap(err, "Invalid healthcheck configuration")
 		}
 	}
+	for i := range raw.FileChecks {
+		check := raw.FileChecks[i]
+		err := check.Validate()
+		if err != nil {
+			return errors.Wrap(err, "Invalid healthcheck configuration")
+		}
+	}
+	for i := range raw.ProcessChecks {
+		check := raw.ProcessChecks[i]
+		err := check.Validate()
+		if err != nil {
+			return errors.Wrap(err, "Invalid healthcheck configuration")
+		}
+	}
+	for i := range raw.HostChecks {
+		check := raw.HostChecks[i]
+		err := check.Validate()
+		if err != nil {
+			return errors.Wrap(err, "Invalid healthcheck configuration")
+		}
+	}
+	for i := range raw.PrometheusChecks {
+		check := raw.PrometheusChecks[i]
+		err := check.Validate()
+		if err != nil {
+			return errors.Wrap(err, "Invalid healthcheck configuration")
+		}
+	}
+	for i := range raw.WebSocketChecks {
+		check := raw.WebSocketChecks[i]
+		err := check.Validate()
+		if err != nil {
+			return errors.Wrap(err, "Invalid healthcheck configuration")
+		}
+	}
+	for i := range raw.MQTTChecks {
+		check := raw.MQTTChecks[i]
+		err := check.Validate()
+		if err != nil {
+			return errors.Wrap(err, "Invalid healthcheck configuration")
+		}
+	}
+	for i := range raw.LDAPChecks {
+		check := raw.LDAPChecks[i]
+		err := check.Validate()
+		if err != nil {
+			return errors.Wrap(err, "Invalid healthcheck configuration")
+		}
+	}
+	for i := range raw.SSHChecks {
+		check := raw.SSHChecks[i]
+		err := check.Validate()
+		if err != nil {
+			return errors.Wrap(err, "Invalid healthcheck configuration")
+		}
+	}
+	for i := range raw.NTPChecks {
+		check := raw.NTPChecks[i]
+		err := check.Validate()
+		if err != nil {
+			return errors.Wrap(err, "Invalid healthcheck configuration")
+		}
+	}
+	for i := range raw.SNMPChecks {
+		check := raw.SNMPChecks[i]
+		err := check.Validate()
+		if err != nil {
+			return errors.Wrap(err, "Invalid healthcheck configuration")
+		}
+	}
+	for i := range raw.DockerChecks {
+		check := raw.DockerChecks[i]
+		err := check.Validate()
+		if err != nil {
+			return errors.Wrap(err, "Invalid healthcheck configuration")
+		}
+	}
+	for i := range raw.Silences {
+		silence := raw.Silences[i]
+		err := silence.Validate()
+		if err != nil {
+			return errors.Wrap(err, "Invalid silence configuration")
+		}
+	}
 	if raw.ResultBuffer == 0 {
 		raw.ResultBuffer = chanSize
 	}
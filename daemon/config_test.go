@@ -375,6 +375,45 @@ exporters:
 				},
 			},
 		},
+		{
+			in: `
+http:
+  host: "127.0.0.1"
+  port: 2000
+silences:
+  - labels:
+      environment: prod
+    time-zone: Europe/Paris
+    comment: weekly maintenance
+    windows:
+      - days: [sun]
+        start: "02:00"
+        end: "04:00"
+`,
+			want: Configuration{
+				ResultBuffer: DefaultBufferSize,
+				HTTP: http.Configuration{
+					Host: "127.0.0.1",
+					Port: 2000,
+				},
+				Silences: []healthcheck.RecurringSilence{
+					{
+						Labels: map[string]string{
+							"environment": "prod",
+						},
+						TimeZone: "Europe/Paris",
+						Comment:  "weekly maintenance",
+						Windows: []healthcheck.ActiveWindow{
+							{
+								Days:  []string{"sun"},
+								Start: "02:00",
+								End:   "04:00",
+							},
+						},
+					},
+				},
+			},
+		},
 	}
 	for _, c := range cases {
 		var result Configuration
@@ -513,6 +552,16 @@ http-checks:
       - 201
     labels:
       environment: prod
+`,
+		`
+http:
+  host: 127.0.0.1
+  port: 2000
+silences:
+  - names: [foo]
+    windows:
+      - start: "25:00"
+        end: "04:00"
 `,
 	}
 	for _, c := range cases {
@@ -87,6 +87,7 @@ func New(logger *zap.Logger, config *Configuration) (*Component, error) {
 	if err != nil {
 		return nil, err
 	}
+	checkComponent.Silences.SetRecurring(config.Silences)
 	return &component, nil
 }
 
@@ -124,7 +125,18 @@ func (c *Component) ReloadHealthchecks(daemonConfig *Configuration) error {
 		daemonConfig.DNSChecks,
 		daemonConfig.TCPChecks,
 		daemonConfig.HTTPChecks,
-		daemonConfig.TLSChecks)
+		daemonConfig.TLSChecks,
+		daemonConfig.FileChecks,
+		daemonConfig.ProcessChecks,
+		daemonConfig.HostChecks,
+		daemonConfig.PrometheusChecks,
+		daemonConfig.WebSocketChecks,
+		daemonConfig.MQTTChecks,
+		daemonConfig.LDAPChecks,
+		daemonConfig.SSHChecks,
+		daemonConfig.NTPChecks,
+		daemonConfig.SNMPChecks,
+		daemonConfig.DockerChecks)
 }
 
 // Reload reloads the Cabourotte daemon. This function will remove or keep
@@ -138,6 +150,9 @@ func (c *Component) Reload(daemonConfig *Configuration) error {
 	if err != nil {
 		return errors.Wrapf(err, "Fail to reload healthchecks")
 	}
+	// silences created through the API are kept, only the recurring
+	// silences from the configuration are replaced
+	c.Healthcheck.Silences.SetRecurring(daemonConfig.Silences)
 	// compare the server config to see if we need to recreate it
 	if !reflect.DeepEqual(c.Config.HTTP, daemonConfig.HTTP) {
 		err := c.HTTP.Stop()
@@ -22,11 +22,22 @@ type Configuration struct {
 }
 
 type ResultPayload struct {
-	CommandChecks []healthcheck.CommandHealthcheckConfiguration `json:"command-checks"`
-	DNSChecks     []healthcheck.DNSHealthcheckConfiguration     `json:"dns-checks"`
-	TCPChecks     []healthcheck.TCPHealthcheckConfiguration     `json:"tcp-checks"`
-	HTTPChecks    []healthcheck.HTTPHealthcheckConfiguration    `json:"http-checks"`
-	TLSChecks     []healthcheck.TLSHealthcheckConfiguration     `json:"tls-checks"`
+	CommandChecks    []healthcheck.CommandHealthcheckConfiguration    `json:"command-checks"`
+	DNSChecks        []healthcheck.DNSHealthcheckConfiguration        `json:"dns-checks"`
+	TCPChecks        []healthcheck.TCPHealthcheckConfiguration        `json:"tcp-checks"`
+	HTTPChecks       []healthcheck.HTTPHealthcheckConfiguration       `json:"http-checks"`
+	TLSChecks        []healthcheck.TLSHealthcheckConfiguration        `json:"tls-checks"`
+	FileChecks       []healthcheck.FileHealthcheckConfiguration       `json:"file-checks"`
+	ProcessChecks    []healthcheck.ProcessHealthcheckConfiguration    `json:"process-checks"`
+	HostChecks       []healthcheck.HostHealthcheckConfiguration       `json:"host-checks"`
+	PrometheusChecks []healthcheck.PrometheusHealthcheckConfiguration `json:"prometheus-checks"`
+	WebSocketChecks  []healthcheck.WebSocketHealthcheckConfiguration  `json:"websocket-checks"`
+	MQTTChecks       []healthcheck.MQTTHealthcheckConfiguration       `json:"mqtt-checks"`
+	LDAPChecks       []healthcheck.LDAPHealthcheckConfiguration       `json:"ldap-checks"`
+	SSHChecks        []healthcheck.SSHHealthcheckConfiguration        `json:"ssh-checks"`
+	NTPChecks        []healthcheck.NTPHealthcheckConfiguration        `json:"ntp-checks"`
+	SNMPChecks       []healthcheck.SNMPHealthcheckConfiguration       `json:"snmp-checks"`
+	DockerChecks     []healthcheck.DockerHealthcheckConfiguration     `json:"docker-checks"`
 }
 
 // UnmarshalYAML Parse a configuration from YAML.
@@ -109,7 +109,18 @@ func (c *HTTPDiscovery) request() error {
 		payload.DNSChecks,
 		payload.TCPChecks,
 		payload.HTTPChecks,
-		payload.TLSChecks)
+		payload.TLSChecks,
+		payload.FileChecks,
+		payload.ProcessChecks,
+		payload.HostChecks,
+		payload.PrometheusChecks,
+		payload.WebSocketChecks,
+		payload.MQTTChecks,
+		payload.LDAPChecks,
+		payload.SSHChecks,
+		payload.NTPChecks,
+		payload.SNMPChecks,
+		payload.DockerChecks)
 }
 
 // Start starts the HTTP discovery component
@@ -80,6 +80,22 @@ insecure: true
 				Insecure: true,
 			},
 		},
+		{
+			in: `
+host: "127.0.0.2"
+port: 2003
+protocol: http
+name: foo
+drop-silenced: true
+`,
+			want: HTTPConfiguration{
+				Name:         "foo",
+				Host:         "127.0.0.2",
+				Port:         2003,
+				Protocol:     healthcheck.HTTP,
+				DropSilenced: true,
+			},
+		},
 	}
 	for _, c := range cases {
 		var result HTTPConfiguration
@@ -27,6 +27,8 @@ type HTTPConfiguration struct {
 	Cert     string            `json:"cert,omitempty"`
 	Cacert   string            `json:"cacert,omitempty"`
 	Insecure bool
+	// silenced healthchecks results are not sent to the exporter
+	DropSilenced bool `json:"drop-silenced,omitempty" yaml:"drop-silenced,omitempty"`
 }
 
 // HTTPExporter the http exporter struct
@@ -133,6 +135,11 @@ func (c *HTTPExporter) GetConfig() interface{} {
 	return c.Config
 }
 
+// DropSilenced returns true if silenced results should not be pushed
+func (c *HTTPExporter) DropSilenced() bool {
+	return c.Config.DropSilenced
+}
+
 // Push pushes events to the HTTP destination
 func (c *HTTPExporter) Push(result *healthcheck.Result) error {
 	var jsonBytes []byte
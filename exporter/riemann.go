@@ -23,6 +23,8 @@ type RiemannConfiguration struct {
 	Cert     string `json:"cert,omitempty"`
 	Cacert   string `json:"cacert,omitempty"`
 	Insecure bool
+	// silenced healthchecks results are not sent to the exporter
+	DropSilenced bool `json:"drop-silenced,omitempty" yaml:"drop-silenced,omitempty"`
 }
 
 // RiemannExporter the Riemann exporter struct
@@ -144,16 +146,33 @@ func (c *RiemannExporter) IsStarted() bool {
 	return c.Started
 }
 
+// DropSilenced returns true if silenced results should not be pushed
+func (c *RiemannExporter) DropSilenced() bool {
+	return c.Config.DropSilenced
+}
+
 // Push pushes events to the desination
 func (c *RiemannExporter) Push(result *healthcheck.Result) error {
-	state := "ok"
-	if !result.Success {
-		state = "critical"
+	state := string(result.Status)
+	if state == "" {
+		state = string(healthcheck.StatusOK)
+		if !result.Success {
+			state = string(healthcheck.StatusCritical)
+		}
 	}
 	attributes := map[string]string{
 		"healthcheck": result.Name,
 		"source":      result.Source,
 	}
+	if result.RawStatus != "" {
+		attributes["raw-status"] = string(result.RawStatus)
+	}
+	if result.Flapping {
+		attributes["flapping"] = "true"
+	}
+	if result.Silenced {
+		attributes["silenced"] = "true"
+	}
 	for k, v := range result.Labels {
 		attributes[k] = v
 	}
@@ -23,6 +23,7 @@ type Exporter interface {
 	IsStarted() bool
 	Name() string
 	GetConfig() interface{}
+	DropSilenced() bool
 	Push(*healthcheck.Result) error
 }
 
@@ -140,6 +141,9 @@ func (c *Component) Start() error {
 			}
 			for k := range c.Exporters {
 				exporter := c.Exporters[k]
+				if message.Silenced && exporter.DropSilenced() {
+					continue
+				}
 				if exporter.IsStarted() {
 					start := time.Now()
 					err := exporter.Push(message)
@@ -5,19 +5,45 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"os"
 	"os/exec"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
+	"golang.org/x/crypto/ssh"
 )
 
+// defaultOutputLimit the default maximum size of the stdout and stderr
+// outputs captured for a command
+const defaultOutputLimit = 4096
+
 // CommandHealthcheckConfiguration defines a COMMAND healthcheck configuration
 type CommandHealthcheckConfiguration struct {
-	Base      `json:",inline" yaml:",inline"`
-	Command   string   `json:"command"`
-	Arguments []string `json:"arguments"`
-	Timeout   Duration `json:"timeout"`
+	Base        `json:",inline" yaml:",inline"`
+	Command     string            `json:"command"`
+	Arguments   []string          `json:"arguments"`
+	Timeout     Duration          `json:"timeout"`
+	Env         map[string]string `json:"env,omitempty"`
+	ClearEnv    bool              `json:"clear-env" yaml:"clear-env"`
+	WorkingDir  string            `json:"working-dir,omitempty" yaml:"working-dir,omitempty"`
+	Shell       bool              `json:"shell"`
+	User        string            `json:"user,omitempty"`
+	Group       string            `json:"group,omitempty"`
+	Stdin       string            `json:"stdin,omitempty"`
+	OutputLimit uint              `json:"output-limit,omitempty" yaml:"output-limit,omitempty"`
+	JSONOutput  bool              `json:"json-output" yaml:"json-output"`
+	// resource limits, applied to the command process
+	CPUTimeLimit   Duration `json:"cpu-time-limit,omitempty" yaml:"cpu-time-limit,omitempty"`
+	MemoryLimit    uint64   `json:"memory-limit,omitempty" yaml:"memory-limit,omitempty"`
+	ProcessesLimit uint64   `json:"processes-limit,omitempty" yaml:"processes-limit,omitempty"`
+	OpenFilesLimit uint64   `json:"open-files-limit,omitempty" yaml:"open-files-limit,omitempty"`
+	NoNetwork      bool     `json:"no-network" yaml:"no-network"`
+	// executes the command on a remote host over SSH
+	Remote *CommandRemoteConfiguration `json:"remote,omitempty"`
 }
 
 // CommandHealthcheck defines an HTTP healthcheck
@@ -26,13 +52,49 @@ type CommandHealthcheck struct {
 	Config *CommandHealthcheckConfiguration
 	URL    string
 
-	Tick *time.Ticker
+	Tick        *time.Ticker
+	sysProcAttr *syscall.SysProcAttr
+
+	// the SSH connection is kept between executions for remote commands
+	lock      sync.Mutex
+	sshConfig *ssh.ClientConfig
+	client    *ssh.Client
+}
+
+// commandWaitDelay is the delay given to the outputs of a killed command to
+// be closed. It is shorter than the wrapper grace period so the details of
+// a command which timed out are reported.
+const commandWaitDelay = timeoutGracePeriod / 2
+
+// limitedBuffer is a buffer keeping only the first bytes written into it
+type limitedBuffer struct {
+	buffer    bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+// Write writes into the buffer, discarding the bytes exceeding the limit
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	remaining := b.limit - b.buffer.Len()
+	if remaining < len(p) {
+		b.truncated = true
+		if remaining > 0 {
+			b.buffer.Write(p[:remaining])
+		}
+		return len(p), nil
+	}
+	return b.buffer.Write(p)
+}
+
+// String returns the buffer content
+func (b *limitedBuffer) String() string {
+	return b.buffer.String()
 }
 
 // Validate validates the healthcheck configuration
 func (config *CommandHealthcheckConfiguration) Validate() error {
-	if config.Base.Name == "" {
-		return errors.New("The healthcheck name is missing")
+	if err := config.Base.validate(); err != nil {
+		return err
 	}
 	if config.Command == "" {
 		return errors.New("The healthcheck command is missing")
@@ -40,7 +102,16 @@ func (config *CommandHealthcheckConfiguration) Validate() error {
 	if config.Timeout == 0 {
 		return errors.New("The healthcheck timeout is missing")
 	}
-	if !config.Base.OneOff {
+	if config.Group != "" && config.User == "" {
+		return errors.New("The healthcheck group can only be set alongside the user")
+	}
+	if config.CPUTimeLimit != 0 && config.CPUTimeLimit < Duration(time.Second) {
+		return errors.New("The healthcheck CPU time limit should be greater than 1 second")
+	}
+	if err := config.Base.validateTimeout(config.Timeout); err != nil {
+		return err
+	}
+	if !config.Base.OneOff && config.Base.Schedule == "" {
 		if config.Base.Interval < Duration(2*time.Second) {
 			return errors.New("The healthcheck interval should be greater than 2 second")
 		}
@@ -48,11 +119,27 @@ func (config *CommandHealthcheckConfiguration) Validate() error {
 			return errors.New("The healthcheck interval should be greater than the timeout")
 		}
 	}
+	if config.Remote != nil {
+		return config.validateRemote()
+	}
 	return nil
 }
 
+// hasLimits returns true if resource limits are configured for the command
+func (config *CommandHealthcheckConfiguration) hasLimits() bool {
+	return config.CPUTimeLimit != 0 || config.MemoryLimit != 0 || config.ProcessesLimit != 0 || config.OpenFilesLimit != 0
+}
+
 // Initialize the healthcheck.
 func (h *CommandHealthcheck) Initialize() error {
+	if h.Config.Remote != nil {
+		return h.initializeRemote()
+	}
+	sysProcAttr, err := buildSysProcAttr(h.Config)
+	if err != nil {
+		return err
+	}
+	h.sysProcAttr = sysProcAttr
 	return nil
 }
 
@@ -71,6 +158,11 @@ func (h *CommandHealthcheck) SetSource(source string) {
 	h.Config.Base.Source = source
 }
 
+// Timeout returns the healthcheck timeout
+func (h *CommandHealthcheck) Timeout() time.Duration {
+	return time.Duration(h.Config.Timeout)
+}
+
 // Summary returns an healthcheck summary
 func (h *CommandHealthcheck) Summary() string {
 	summary := ""
@@ -80,6 +172,9 @@ func (h *CommandHealthcheck) Summary() string {
 	} else {
 		summary = fmt.Sprintf("command %s", h.Config.Command)
 	}
+	if h.Config.Remote != nil {
+		summary = fmt.Sprintf("%s on %s", summary, h.Config.Remote.Host)
+	}
 
 	return summary
 }
@@ -106,25 +201,122 @@ func (h *CommandHealthcheck) LogInfo(message string) {
 		zap.String("name", h.Config.Base.Name))
 }
 
+// buildEnv returns the environment of the command
+func (h *CommandHealthcheck) buildEnv() []string {
+	env := []string{}
+	if !h.Config.ClearEnv {
+		env = append(env, os.Environ()...)
+	}
+	for k, v := range h.Config.Env {
+		env = append(env, fmt.Sprintf("%s=%s", k, v))
+	}
+	return env
+}
+
+// buildCommand builds the command to execute, using a shell in shell mode
+func (h *CommandHealthcheck) buildCommand(ctx context.Context) *exec.Cmd {
+	if h.Config.Shell {
+		shell, arguments := shellCommand(h.Config.Command, h.Config.Arguments)
+		return exec.CommandContext(ctx, shell, arguments...)
+	}
+	return exec.CommandContext(ctx, h.Config.Command, h.Config.Arguments...)
+}
+
 // Execute executes an healthcheck on the given domain
-func (h *CommandHealthcheck) Execute() error {
+func (h *CommandHealthcheck) Execute(ctx context.Context) error {
+	_, err := h.ExecuteWithDetails(ctx)
+	return err
+}
+
+// ExecuteWithDetails executes the command, returning its exit code, its
+// outputs and how it terminated as details
+func (h *CommandHealthcheck) ExecuteWithDetails(ctx context.Context) (map[string]interface{}, error) {
 	h.LogDebug("start executing healthcheck")
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(h.Config.Timeout)*time.Second)
-	defer cancel()
-	var stdErr bytes.Buffer
-	cmd := exec.CommandContext(ctx, h.Config.Command, h.Config.Arguments...)
-	cmd.Stderr = &stdErr
-	if err := cmd.Run(); err != nil {
+	if h.Config.Remote != nil {
+		return h.executeRemote(ctx)
+	}
+	limit := int(h.Config.OutputLimit)
+	if limit == 0 {
+		limit = defaultOutputLimit
+	}
+	stdOut := &limitedBuffer{limit: limit}
+	stdErr := &limitedBuffer{limit: limit}
+	cmd := h.buildCommand(ctx)
+	cmd.Stdout = stdOut
+	cmd.Stderr = stdErr
+	cmd.Env = h.buildEnv()
+	cmd.Dir = h.Config.WorkingDir
+	cmd.SysProcAttr = h.sysProcAttr
+	// the whole process group is killed on timeout, and processes keeping
+	// the outputs open should not block the healthcheck
+	cmd.Cancel = func() error {
+		return killProcessGroup(cmd.Process)
+	}
+	cmd.WaitDelay = commandWaitDelay
+	if h.Config.Stdin != "" {
+		cmd.Stdin = strings.NewReader(h.Config.Stdin)
+	}
+	// the command is held until its resource limits are applied
+	release, err := holdCommand(cmd, h.Config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Fail to apply the resource limits on the command")
+	}
+	err = cmd.Start()
+	if err != nil {
+		_ = release(nil)
+	} else {
+		limitErr := release(cmd.Process)
+		if limitErr != nil {
+			_ = cmd.Wait()
+			return nil, errors.Wrapf(limitErr, "Fail to apply the resource limits on the command")
+		}
+		err = cmd.Wait()
+	}
+	details := map[string]interface{}{
+		"stdout":    stdOut.String(),
+		"stderr":    stdErr.String(),
+		"exit-code": cmd.ProcessState.ExitCode(),
+	}
+	if stdOut.truncated || stdErr.truncated {
+		details["truncated"] = true
+	}
+	if cmd.ProcessState != nil {
+		termination, signal := terminationStatus(cmd.ProcessState)
+		if ctx.Err() == context.DeadlineExceeded {
+			termination = "timeout"
+		}
+		details["termination"] = termination
+		if signal != "" {
+			details["signal"] = signal
+		}
+	}
+	if err != nil {
 		var errorMsg string
 		exitErr, isExitError := err.(*exec.ExitError)
-		if isExitError {
+		if ctx.Err() == context.DeadlineExceeded {
+			errorMsg = fmt.Sprintf("The command timed out, stderr=%s", stdErr.String())
+		} else if isExitError {
 			errorMsg = fmt.Sprintf("The command failed with code=%d, stderr=%s", exitErr.ExitCode(), stdErr.String())
 		} else {
 			errorMsg = fmt.Sprintf("The command failed, stderr=%s", stdErr.String())
 		}
-		return errors.Wrapf(err, errorMsg)
+		return details, errors.Wrapf(err, errorMsg)
 	}
+	return details, h.parseJSONOutput(stdOut, details)
+}
 
+// parseJSONOutput adds the command output to the details if the output is
+// JSON
+func (h *CommandHealthcheck) parseJSONOutput(stdOut *limitedBuffer, details map[string]interface{}) error {
+	if !h.Config.JSONOutput {
+		return nil
+	}
+	var output interface{}
+	err := json.Unmarshal(stdOut.buffer.Bytes(), &output)
+	if err != nil {
+		return errors.Wrapf(err, "Fail to parse the command output as JSON")
+	}
+	details["output"] = output
 	return nil
 }
 
@@ -136,9 +328,13 @@ func NewCommandHealthcheck(logger *zap.Logger, config *CommandHealthcheckConfigu
 	}
 }
 
-// MarshalJSON marshal to json a command healthcheck
+// MarshalJSON marshal to json a command healthcheck, the remote private key passphrase is not exposed
 func (h *CommandHealthcheck) MarshalJSON() ([]byte, error) {
-	return json.Marshal(h.Config)
+	config := h.Config.DeepCopy()
+	if config.Remote != nil {
+		config.Remote.Passphrase = ""
+	}
+	return json.Marshal(config)
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -150,6 +346,18 @@ func (in *CommandHealthcheckConfiguration) DeepCopyInto(out *CommandHealthcheckC
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Remote != nil {
+		in, out := &in.Remote, &out.Remote
+		*out = new(CommandRemoteConfiguration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CommandHealthcheckConfiguration.
@@ -0,0 +1,87 @@
+package healthcheck
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/sys/unix"
+)
+
+// isolate configures the namespaces of the command process
+func isolate(attr *syscall.SysProcAttr, config *CommandHealthcheckConfiguration) error {
+	if config.NoNetwork {
+		attr.Cloneflags |= syscall.CLONE_NEWNET
+	}
+	return nil
+}
+
+// limitsWrapper waits for its file descriptor 3 to be closed and then
+// executes the command passed as arguments
+const limitsWrapper = `read _ <&3; exec 3<&- "$0" "$@"`
+
+// holdCommand makes a command wait for its resource limits before executing.
+// The command is started through a shell blocked on a pipe. The returned
+// function applies the limits on the shell process and then releases it, so
+// the command and all its children are limited from their start. It should
+// be called with the started process, or with nil if the command did not
+// start.
+func holdCommand(cmd *exec.Cmd, config *CommandHealthcheckConfiguration) (func(process *os.Process) error, error) {
+	// the command can not be started if it was not found
+	if !config.hasLimits() || cmd.Err != nil {
+		return func(process *os.Process) error {
+			return nil
+		}, nil
+	}
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return nil, errors.Wrap(err, "Fail to create the pipe holding the command")
+	}
+	cmd.ExtraFiles = []*os.File{reader}
+	cmd.Args = append([]string{"/bin/sh", "-c", limitsWrapper, cmd.Path}, cmd.Args[1:]...)
+	cmd.Path = "/bin/sh"
+	return func(process *os.Process) error {
+		reader.Close()
+		defer writer.Close()
+		if process == nil {
+			return nil
+		}
+		err := applyLimits(process.Pid, config)
+		if err != nil {
+			// the command should not be released without its limits
+			_ = killProcessGroup(process)
+		}
+		return err
+	}, nil
+}
+
+// applyLimits sets the resource limits of a process. The limits are
+// inherited by its children.
+func applyLimits(pid int, config *CommandHealthcheckConfiguration) error {
+	limits := map[int]uint64{}
+	if config.CPUTimeLimit != 0 {
+		limits[unix.RLIMIT_CPU] = uint64(time.Duration(config.CPUTimeLimit) / time.Second)
+	}
+	if config.MemoryLimit != 0 {
+		limits[unix.RLIMIT_AS] = config.MemoryLimit
+	}
+	if config.ProcessesLimit != 0 {
+		limits[unix.RLIMIT_NPROC] = config.ProcessesLimit
+	}
+	if config.OpenFilesLimit != 0 {
+		limits[unix.RLIMIT_NOFILE] = config.OpenFilesLimit
+	}
+	for resource, value := range limits {
+		limit := unix.Rlimit{
+			Cur: value,
+			Max: value,
+		}
+		err := unix.Prlimit(pid, resource, &limit, nil)
+		if err != nil {
+			return errors.Wrapf(err, "Fail to set the resource limit %d to %d", resource, value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+//go:build !linux && !windows
+
+package healthcheck
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// isolate configures the namespaces of the command process
+func isolate(attr *syscall.SysProcAttr, config *CommandHealthcheckConfiguration) error {
+	if config.NoNetwork {
+		return errors.New("Disabling the network of a command is only supported on Linux")
+	}
+	if config.hasLimits() {
+		return errors.New("Resource limits for commands are only supported on Linux")
+	}
+	return nil
+}
+
+// holdCommand makes a command wait for its resource limits before executing.
+// Resource limits are not supported on this platform.
+func holdCommand(cmd *exec.Cmd, config *CommandHealthcheckConfiguration) (func(process *os.Process) error, error) {
+	return func(process *os.Process) error {
+		return nil
+	}, nil
+}
@@ -0,0 +1,286 @@
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// CommandRemoteConfiguration defines the SSH target on which a command is
+// executed
+type CommandRemoteConfiguration struct {
+	// can be an IP or a domain
+	Host string `json:"host"`
+	Port uint   `json:"port,omitempty"`
+	User string `json:"user"`
+	// path to the private key used for authentication
+	PrivateKey string `json:"private-key" yaml:"private-key"`
+	Passphrase string `json:"passphrase,omitempty"`
+	// path to the known_hosts file used to verify the host key
+	KnownHosts string `json:"known-hosts" yaml:"known-hosts"`
+}
+
+// validate validates the remote configuration
+func (config *CommandRemoteConfiguration) validate() error {
+	if config.Host == "" {
+		return errors.New("The healthcheck remote host is missing")
+	}
+	if config.Port == 0 {
+		config.Port = 22
+	}
+	if config.User == "" {
+		return errors.New("The healthcheck remote user is missing")
+	}
+	if config.PrivateKey == "" {
+		return errors.New("The healthcheck remote private key is missing")
+	}
+	if config.KnownHosts == "" {
+		return errors.New("The healthcheck remote known hosts file is missing")
+	}
+	return nil
+}
+
+// validateRemote validates the configuration of a command executed over SSH
+func (config *CommandHealthcheckConfiguration) validateRemote() error {
+	err := config.Remote.validate()
+	if err != nil {
+		return err
+	}
+	if config.User != "" || config.Group != "" {
+		return errors.New("The healthcheck user and group are not supported for remote commands")
+	}
+	if len(config.Env) != 0 || config.ClearEnv {
+		return errors.New("The healthcheck environment is not supported for remote commands")
+	}
+	if config.WorkingDir != "" {
+		return errors.New("The healthcheck working directory is not supported for remote commands")
+	}
+	if config.hasLimits() || config.NoNetwork {
+		return errors.New("The healthcheck resource limits and network isolation are not supported for remote commands")
+	}
+	return nil
+}
+
+// initializeRemote builds the SSH client configuration
+func (h *CommandHealthcheck) initializeRemote() error {
+	remote := h.Config.Remote
+	port := remote.Port
+	if port == 0 {
+		port = 22
+	}
+	h.URL = net.JoinHostPort(remote.Host, fmt.Sprintf("%d", port))
+	signer, err := loadSSHSigner(remote.PrivateKey, remote.Passphrase)
+	if err != nil {
+		return err
+	}
+	hostKeyCallback, err := knownhosts.New(remote.KnownHosts)
+	if err != nil {
+		return errors.Wrapf(err, "Fail to read the known hosts file %s", remote.KnownHosts)
+	}
+	h.sshConfig = &ssh.ClientConfig{
+		User:            remote.User,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         time.Duration(h.Config.Timeout),
+	}
+	return nil
+}
+
+// shellQuote quotes a string for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// remoteCommand returns the command line executed on the remote host
+func (h *CommandHealthcheck) remoteCommand() string {
+	parts := []string{}
+	if h.Config.Shell {
+		parts = append(parts, "sh", "-c", shellQuote(h.Config.Command), "sh")
+	} else {
+		parts = append(parts, shellQuote(h.Config.Command))
+	}
+	for _, argument := range h.Config.Arguments {
+		parts = append(parts, shellQuote(argument))
+	}
+	return strings.Join(parts, " ")
+}
+
+// cachedClient returns the SSH connection kept between executions, if any
+func (h *CommandHealthcheck) cachedClient() *ssh.Client {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.client
+}
+
+// sshClient returns the SSH connection to the remote host, reusing the
+// connection opened by a previous execution if any. The lock is only held
+// to read or to replace the kept connection.
+func (h *CommandHealthcheck) sshClient(ctx context.Context) (*ssh.Client, error) {
+	if client := h.cachedClient(); client != nil {
+		return client, nil
+	}
+	dialer := &net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", h.URL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "SSH connection failed on %s", h.URL)
+	}
+	deadline, _ := ctx.Deadline()
+	err = conn.SetDeadline(deadline)
+	if err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "Fail to set the connection deadline")
+	}
+	sshConn, channels, requests, err := ssh.NewClientConn(conn, h.URL, h.sshConfig)
+	if err != nil {
+		conn.Close()
+		return nil, errors.Wrapf(err, "SSH handshake failed on %s", h.URL)
+	}
+	err = conn.SetDeadline(time.Time{})
+	if err != nil {
+		sshConn.Close()
+		return nil, errors.Wrap(err, "Fail to reset the connection deadline")
+	}
+	client := ssh.NewClient(sshConn, channels, requests)
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.client != nil {
+		// another execution opened a connection in the meantime
+		client.Close()
+		return h.client, nil
+	}
+	h.client = client
+	return client, nil
+}
+
+// closeClient closes a SSH connection, forgetting it if it is the one kept
+// between executions
+func (h *CommandHealthcheck) closeClient(client *ssh.Client) error {
+	h.lock.Lock()
+	if h.client == client {
+		h.client = nil
+	}
+	h.lock.Unlock()
+	return client.Close()
+}
+
+// Close closes the SSH connection kept between executions. An execution in
+// progress on this connection fails.
+func (h *CommandHealthcheck) Close() error {
+	h.lock.Lock()
+	client := h.client
+	h.client = nil
+	h.lock.Unlock()
+	if client == nil {
+		return nil
+	}
+	return client.Close()
+}
+
+// newSession opens a SSH session, opening a new connection if the one kept
+// from the previous execution is broken. The connection used by the session
+// is returned alongside it.
+func (h *CommandHealthcheck) newSession(ctx context.Context) (*ssh.Session, *ssh.Client, error) {
+	reused := h.cachedClient() != nil
+	client, err := h.sshClient(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	session, err := client.NewSession()
+	if err != nil && reused {
+		_ = h.closeClient(client)
+		client, err = h.sshClient(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		session, err = client.NewSession()
+	}
+	if err != nil {
+		_ = h.closeClient(client)
+		return nil, nil, errors.Wrap(err, "Fail to open a SSH session")
+	}
+	return session, client, nil
+}
+
+// executeRemote executes the command on the remote host
+func (h *CommandHealthcheck) executeRemote(ctx context.Context) (map[string]interface{}, error) {
+	session, client, err := h.newSession(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if h.Config.Base.OneOff {
+		defer func() { _ = h.closeClient(client) }()
+	}
+	defer session.Close()
+	limit := int(h.Config.OutputLimit)
+	if limit == 0 {
+		limit = defaultOutputLimit
+	}
+	stdOut := &limitedBuffer{limit: limit}
+	stdErr := &limitedBuffer{limit: limit}
+	session.Stdout = stdOut
+	session.Stderr = stdErr
+	if h.Config.Stdin != "" {
+		session.Stdin = strings.NewReader(h.Config.Stdin)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- session.Run(h.remoteCommand())
+	}()
+	timedOut := false
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		timedOut = true
+		_ = session.Signal(ssh.SIGKILL)
+		// the connection state is unknown, a new one is opened for the
+		// next execution
+		_ = h.closeClient(client)
+		err = <-done
+	}
+	exitCode := 0
+	termination := "exited"
+	signal := ""
+	exitErr, isExitError := err.(*ssh.ExitError)
+	if isExitError {
+		exitCode = exitErr.ExitStatus()
+		if exitErr.Signal() != "" {
+			termination = "signaled"
+			signal = exitErr.Signal()
+			exitCode = -1
+		}
+	} else if err != nil {
+		exitCode = -1
+	}
+	if timedOut {
+		termination = "timeout"
+	}
+	details := map[string]interface{}{
+		"stdout":      stdOut.String(),
+		"stderr":      stdErr.String(),
+		"exit-code":   exitCode,
+		"termination": termination,
+	}
+	if stdOut.truncated || stdErr.truncated {
+		details["truncated"] = true
+	}
+	if signal != "" {
+		details["signal"] = signal
+	}
+	if timedOut {
+		return details, fmt.Errorf("The command timed out, stderr=%s", stdErr.String())
+	}
+	if err != nil {
+		if isExitError {
+			return details, errors.Wrapf(err, "The command failed with code=%d, stderr=%s", exitCode, stdErr.String())
+		}
+		_ = h.closeClient(client)
+		return details, errors.Wrapf(err, "The command failed, stderr=%s", stdErr.String())
+	}
+	return details, h.parseJSONOutput(stdOut, details)
+}
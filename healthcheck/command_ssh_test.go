@@ -0,0 +1,205 @@
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// commandTestKnownHosts writes a known_hosts file containing the host key
+func commandTestKnownHosts(t *testing.T, port uint, key ssh.PublicKey) string {
+	path := filepath.Join(t.TempDir(), "known_hosts")
+	line := knownhosts.Line([]string{fmt.Sprintf("[127.0.0.1]:%d", port)}, key)
+	err := os.WriteFile(path, []byte(line+"\n"), 0600)
+	if err != nil {
+		t.Fatalf("Fail to write the known hosts file :\n%v", err)
+	}
+	return path
+}
+
+func TestCommandExecuteRemote(t *testing.T) {
+	keyPath, signer := sshTestKey(t)
+	listener, port, hostSigner := sshTestServer(t, signer.PublicKey())
+	defer listener.Close()
+	h := NewCommandHealthcheck(
+		zap.NewExample(),
+		&CommandHealthcheckConfiguration{
+			Command:   "echo",
+			Arguments: []string{"hello", "it's me"},
+			Timeout:   Duration(time.Second * 2),
+			Remote: &CommandRemoteConfiguration{
+				Host:       "127.0.0.1",
+				Port:       port,
+				User:       "cabourotte",
+				PrivateKey: keyPath,
+				KnownHosts: commandTestKnownHosts(t, port, hostSigner.PublicKey()),
+			},
+		})
+	err := h.Initialize()
+	if err != nil {
+		t.Fatalf("Initialization error :\n%v", err)
+	}
+	defer h.Close()
+	details, err := h.ExecuteWithDetails(context.Background())
+	if err != nil {
+		t.Fatalf("healthcheck error :\n%v", err)
+	}
+	if details["stdout"] != "hello it's me\n" || details["exit-code"] != 0 || details["termination"] != "exited" {
+		t.Fatalf("Invalid details: %v", details)
+	}
+	client := h.client
+	if client == nil {
+		t.Fatalf("The SSH connection was not kept")
+	}
+
+	h.Config.Command = "cat; echo oops >&2; exit 3"
+	h.Config.Arguments = nil
+	h.Config.Shell = true
+	h.Config.Stdin = "input"
+	details, err = h.ExecuteWithDetails(context.Background())
+	if err == nil {
+		t.Fatalf("healthcheck was expected to fail")
+	}
+	if details["stdout"] != "input" || details["stderr"] != "oops\n" || details["exit-code"] != 3 {
+		t.Fatalf("Invalid details: %v", details)
+	}
+	if h.client != client {
+		t.Fatalf("The SSH connection was not reused")
+	}
+
+	err = h.Close()
+	if err != nil {
+		t.Fatalf("Fail to close the healthcheck :\n%v", err)
+	}
+	if h.client != nil {
+		t.Fatalf("The SSH connection was not closed")
+	}
+	h.Config.Command = "true"
+	h.Config.Stdin = ""
+	err = h.Execute(context.Background())
+	if err != nil {
+		t.Fatalf("healthcheck error :\n%v", err)
+	}
+}
+
+func TestCommandCloseRemoteInProgress(t *testing.T) {
+	keyPath, signer := sshTestKey(t)
+	listener, port, hostSigner := sshTestServer(t, signer.PublicKey())
+	defer listener.Close()
+	h := NewCommandHealthcheck(
+		zap.NewExample(),
+		&CommandHealthcheckConfiguration{
+			Command:   "sleep",
+			Arguments: []string{"5"},
+			Timeout:   Duration(time.Second * 10),
+			Remote: &CommandRemoteConfiguration{
+				Host:       "127.0.0.1",
+				Port:       port,
+				User:       "cabourotte",
+				PrivateKey: keyPath,
+				KnownHosts: commandTestKnownHosts(t, port, hostSigner.PublicKey()),
+			},
+		})
+	err := h.Initialize()
+	if err != nil {
+		t.Fatalf("Initialization error :\n%v", err)
+	}
+	result := make(chan error, 1)
+	go func() {
+		result <- h.Execute(context.Background())
+	}()
+	for i := 0; i < 100 && h.cachedClient() == nil; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	start := time.Now()
+	err = h.Close()
+	if err != nil {
+		t.Fatalf("Fail to close the healthcheck :\n%v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Fatalf("Closing the healthcheck waited for the execution")
+	}
+	select {
+	case err = <-result:
+		if err == nil {
+			t.Fatalf("healthcheck was expected to fail")
+		}
+	case <-time.After(time.Second * 3):
+		t.Fatalf("The execution was not interrupted by the close")
+	}
+}
+
+func TestCommandExecuteRemoteUnknownHost(t *testing.T) {
+	keyPath, signer := sshTestKey(t)
+	listener, port, _ := sshTestServer(t, signer.PublicKey())
+	defer listener.Close()
+	_, otherSigner := sshTestKey(t)
+	h := NewCommandHealthcheck(
+		zap.NewExample(),
+		&CommandHealthcheckConfiguration{
+			Command: "true",
+			Timeout: Duration(time.Second * 2),
+			Remote: &CommandRemoteConfiguration{
+				Host:       "127.0.0.1",
+				Port:       port,
+				User:       "cabourotte",
+				PrivateKey: keyPath,
+				KnownHosts: commandTestKnownHosts(t, port, otherSigner.PublicKey()),
+			},
+		})
+	err := h.Initialize()
+	if err != nil {
+		t.Fatalf("Initialization error :\n%v", err)
+	}
+	err = h.Execute(context.Background())
+	if err == nil {
+		t.Fatalf("healthcheck was expected to fail")
+	}
+}
+
+func TestCommandValidateRemote(t *testing.T) {
+	remote := CommandRemoteConfiguration{Host: "127.0.0.1", User: "cabourotte", PrivateKey: "/tmp/key", KnownHosts: "/tmp/known_hosts"}
+	cases := []CommandHealthcheckConfiguration{
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Command: "true", Timeout: Duration(time.Second), Remote: &CommandRemoteConfiguration{User: "cabourotte", PrivateKey: "/tmp/key", KnownHosts: "/tmp/known_hosts"}},
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Command: "true", Timeout: Duration(time.Second), Remote: &CommandRemoteConfiguration{Host: "127.0.0.1", User: "cabourotte", PrivateKey: "/tmp/key"}},
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Command: "true", Timeout: Duration(time.Second), Env: map[string]string{"A": "B"}, Remote: &remote},
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Command: "true", Timeout: Duration(time.Second), User: "nobody", Remote: &remote},
+	}
+	for i := range cases {
+		err := cases[i].Validate()
+		if err == nil {
+			t.Fatalf("Validation was expected to fail for case %d", i)
+		}
+	}
+	valid := CommandHealthcheckConfiguration{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Command: "true", Timeout: Duration(time.Second), Remote: &remote}
+	err := valid.Validate()
+	if err != nil {
+		t.Fatalf("Validation error :\n%v", err)
+	}
+	if valid.Remote.Port != 22 {
+		t.Fatalf("Invalid default port %d", valid.Remote.Port)
+	}
+}
+
+func TestCommandRemoteMarshalJSONRedacted(t *testing.T) {
+	h := NewCommandHealthcheck(zap.NewExample(), &CommandHealthcheckConfiguration{Command: "true", Remote: &CommandRemoteConfiguration{Host: "127.0.0.1", Passphrase: "s3cr3t"}})
+	out, err := json.Marshal(h)
+	if err != nil {
+		t.Fatalf("Fail to marshal the healthcheck :\n%v", err)
+	}
+	if strings.Contains(string(out), "s3cr3t") {
+		t.Fatalf("The credentials were exposed: %s", out)
+	}
+	if h.Config.Remote.Passphrase != "s3cr3t" {
+		t.Fatalf("The healthcheck configuration was modified")
+	}
+}
@@ -1,6 +1,12 @@
 package healthcheck
 
 import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
 	"time"
 
@@ -15,7 +21,7 @@ func TestCommandExecuteSuccess(t *testing.T) {
 			Timeout: Duration(time.Second * 2),
 		},
 	}
-	err := h.Execute()
+	err := h.Execute(context.Background())
 	if err != nil {
 		t.Fatalf("healthcheck error :\n%v", err)
 	}
@@ -30,8 +36,271 @@ func TestCommandExecuteFailure(t *testing.T) {
 			Timeout:   Duration(time.Second * 2),
 		},
 	}
-	err := h.Execute()
+	err := h.Execute(context.Background())
 	if err == nil {
 		t.Fatalf("healthcheck was expected to fail")
 	}
 }
+
+func TestCommandExecuteEnvironment(t *testing.T) {
+	t.Setenv("CABOUROTTE_INHERITED", "inherited")
+	h := CommandHealthcheck{
+		Logger: zap.NewExample(),
+		Config: &CommandHealthcheckConfiguration{
+			Command:    "echo -n \"$CABOUROTTE_INHERITED,$FOO,$(pwd)\"",
+			Shell:      true,
+			Env:        map[string]string{"FOO": "bar"},
+			WorkingDir: "/tmp",
+			Timeout:    Duration(time.Second * 2),
+		},
+	}
+	details, err := h.ExecuteWithDetails(context.Background())
+	if err != nil {
+		t.Fatalf("healthcheck error :\n%v", err)
+	}
+	if details["stdout"] != "inherited,bar,/tmp" {
+		t.Fatalf("Invalid stdout: %s", details["stdout"])
+	}
+	h.Config.ClearEnv = true
+	details, err = h.ExecuteWithDetails(context.Background())
+	if err != nil {
+		t.Fatalf("healthcheck error :\n%v", err)
+	}
+	if details["stdout"] != ",bar,/tmp" {
+		t.Fatalf("Invalid stdout: %s", details["stdout"])
+	}
+}
+
+func TestCommandExecuteOutputs(t *testing.T) {
+	h := CommandHealthcheck{
+		Logger: zap.NewExample(),
+		Config: &CommandHealthcheckConfiguration{
+			Command:     "cat; echo -n error >&2; exit $1",
+			Arguments:   []string{"3"},
+			Shell:       true,
+			Stdin:       "hello world",
+			OutputLimit: 5,
+			Timeout:     Duration(time.Second * 2),
+		},
+	}
+	details, err := h.ExecuteWithDetails(context.Background())
+	if err == nil {
+		t.Fatalf("healthcheck was expected to fail")
+	}
+	if details["stdout"] != "hello" {
+		t.Fatalf("Invalid stdout: %s", details["stdout"])
+	}
+	if details["stderr"] != "error" {
+		t.Fatalf("Invalid stderr: %s", details["stderr"])
+	}
+	if details["exit-code"] != 3 {
+		t.Fatalf("Invalid exit code: %v", details["exit-code"])
+	}
+	if details["truncated"] != true {
+		t.Fatalf("The stdout should be truncated")
+	}
+}
+
+func TestCommandExecuteJSONOutput(t *testing.T) {
+	h := CommandHealthcheck{
+		Logger: zap.NewExample(),
+		Config: &CommandHealthcheckConfiguration{
+			Command:    "echo",
+			Arguments:  []string{`{"queue": 10}`},
+			JSONOutput: true,
+			Timeout:    Duration(time.Second * 2),
+		},
+	}
+	details, err := h.ExecuteWithDetails(context.Background())
+	if err != nil {
+		t.Fatalf("healthcheck error :\n%v", err)
+	}
+	output, ok := details["output"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("Invalid output: %v", details["output"])
+	}
+	if output["queue"] != float64(10) {
+		t.Fatalf("Invalid output: %v", output)
+	}
+	h.Config.Arguments = []string{"not json"}
+	_, err = h.ExecuteWithDetails(context.Background())
+	if err == nil {
+		t.Fatalf("healthcheck was expected to fail")
+	}
+}
+
+func TestCommandExecuteUser(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("changing the command user requires root")
+	}
+	h := NewCommandHealthcheck(
+		zap.NewExample(),
+		&CommandHealthcheckConfiguration{
+			Command:   "id",
+			Arguments: []string{"-u"},
+			User:      "nobody",
+			Timeout:   Duration(time.Second * 2),
+		})
+	err := h.Initialize()
+	if err != nil {
+		t.Fatalf("Fail to initialize the healthcheck :\n%v", err)
+	}
+	details, err := h.ExecuteWithDetails(context.Background())
+	if err != nil {
+		t.Fatalf("healthcheck error :\n%v", err)
+	}
+	if details["stdout"] == "0\n" {
+		t.Fatalf("The command was executed as root")
+	}
+}
+
+func TestCommandExecuteTimeoutKillsProcessGroup(t *testing.T) {
+	h := NewCommandHealthcheck(
+		zap.NewExample(),
+		&CommandHealthcheckConfiguration{
+			Command: "sleep 10 & sleep 10",
+			Shell:   true,
+			Timeout: Duration(time.Millisecond * 500),
+		})
+	err := h.Initialize()
+	if err != nil {
+		t.Fatalf("Fail to initialize the healthcheck :\n%v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), h.Timeout())
+	defer cancel()
+	start := time.Now()
+	details, err := h.ExecuteWithDetails(ctx)
+	if err == nil {
+		t.Fatalf("healthcheck was expected to fail")
+	}
+	if time.Since(start) > 3*time.Second {
+		t.Fatalf("The process group was not killed on timeout")
+	}
+	if details["termination"] != "timeout" {
+		t.Fatalf("Invalid termination: %v", details["termination"])
+	}
+}
+
+func TestCommandExecuteLimits(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("resource limits are only supported on Linux")
+	}
+	h := NewCommandHealthcheck(
+		zap.NewExample(),
+		&CommandHealthcheckConfiguration{
+			// the limits are already applied on the children started
+			// immediately
+			Command:        "sh -c 'ulimit -n'",
+			Shell:          true,
+			OpenFilesLimit: 42,
+			Timeout:        Duration(time.Second * 5),
+		})
+	err := h.Initialize()
+	if err != nil {
+		t.Fatalf("Fail to initialize the healthcheck :\n%v", err)
+	}
+	details, err := h.ExecuteWithDetails(context.Background())
+	if err != nil {
+		t.Fatalf("healthcheck error :\n%v", err)
+	}
+	if details["stdout"] != "42\n" {
+		t.Fatalf("Invalid open files limit: %v", details["stdout"])
+	}
+	h.Config.Shell = false
+	h.Config.Command = "sh"
+	h.Config.Arguments = []string{"-c", "ulimit -n; echo \"$0\"", "foo bar"}
+	details, err = h.ExecuteWithDetails(context.Background())
+	if err != nil {
+		t.Fatalf("healthcheck error :\n%v", err)
+	}
+	if details["stdout"] != "42\nfoo bar\n" {
+		t.Fatalf("Invalid output: %v", details["stdout"])
+	}
+	h.Config.Shell = true
+	h.Config.Arguments = nil
+	h.Config.OpenFilesLimit = 0
+	h.Config.CPUTimeLimit = Duration(time.Second)
+	h.Config.Command = "while :; do :; done"
+	details, err = h.ExecuteWithDetails(context.Background())
+	if err == nil {
+		t.Fatalf("healthcheck was expected to fail")
+	}
+	if details["termination"] != "signaled" {
+		t.Fatalf("Invalid termination: %v", details["termination"])
+	}
+}
+
+func TestHoldCommand(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("resource limits are only supported on Linux")
+	}
+	config := &CommandHealthcheckConfiguration{
+		OpenFilesLimit: 42,
+	}
+	output, err := os.Create(filepath.Join(t.TempDir(), "output"))
+	if err != nil {
+		t.Fatalf("Fail to create the output file :\n%v", err)
+	}
+	defer output.Close()
+	cmd := exec.Command("sh", "-c", "ulimit -n")
+	cmd.Stdout = output
+	release, err := holdCommand(cmd, config)
+	if err != nil {
+		t.Fatalf("Fail to hold the command :\n%v", err)
+	}
+	err = cmd.Start()
+	if err != nil {
+		t.Fatalf("Fail to start the command :\n%v", err)
+	}
+	// the command should not be executed before its release
+	time.Sleep(200 * time.Millisecond)
+	content, err := os.ReadFile(output.Name())
+	if err != nil {
+		t.Fatalf("Fail to read the output file :\n%v", err)
+	}
+	if len(content) != 0 {
+		t.Fatalf("The command was executed before its release: %s", content)
+	}
+	err = release(cmd.Process)
+	if err != nil {
+		t.Fatalf("Fail to release the command :\n%v", err)
+	}
+	err = cmd.Wait()
+	if err != nil {
+		t.Fatalf("Command error :\n%v", err)
+	}
+	content, err = os.ReadFile(output.Name())
+	if err != nil {
+		t.Fatalf("Fail to read the output file :\n%v", err)
+	}
+	if string(content) != "42\n" {
+		t.Fatalf("Invalid open files limit: %s", content)
+	}
+}
+
+func TestCommandExecuteNoNetwork(t *testing.T) {
+	if runtime.GOOS != "linux" || os.Geteuid() != 0 {
+		t.Skip("network namespaces require root on Linux")
+	}
+	h := NewCommandHealthcheck(
+		zap.NewExample(),
+		&CommandHealthcheckConfiguration{
+			Command:   "cat",
+			Arguments: []string{"/proc/self/net/dev"},
+			NoNetwork: true,
+			Timeout:   Duration(time.Second * 2),
+		})
+	err := h.Initialize()
+	if err != nil {
+		t.Fatalf("Fail to initialize the healthcheck :\n%v", err)
+	}
+	details, err := h.ExecuteWithDetails(context.Background())
+	if err != nil {
+		t.Fatalf("healthcheck error :\n%v", err)
+	}
+	// only the loopback interface is available
+	lines := strings.Split(strings.TrimSpace(details["stdout"].(string)), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[2], "lo:") {
+		t.Fatalf("Invalid network interfaces: %v", details["stdout"])
+	}
+}
@@ -0,0 +1,105 @@
+//go:build !windows
+
+package healthcheck
+
+import (
+	"os"
+	"os/user"
+	"strconv"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// shellCommand returns the shell and its arguments used to execute a
+// command in shell mode. The arguments are passed as positional parameters.
+func shellCommand(command string, arguments []string) (string, []string) {
+	return "/bin/sh", append([]string{"-c", command, "sh"}, arguments...)
+}
+
+// lookupUser returns the uid and the primary gid of an user, which can be
+// a name or an uid
+func lookupUser(name string) (uint32, uint32, error) {
+	u, err := user.Lookup(name)
+	if err != nil {
+		var idErr error
+		u, idErr = user.LookupId(name)
+		if idErr != nil {
+			return 0, 0, errors.Wrapf(err, "Fail to find the user %s", name)
+		}
+	}
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
+	if err != nil {
+		return 0, 0, errors.Wrapf(err, "Invalid uid for user %s", name)
+	}
+	gid, err := strconv.ParseUint(u.Gid, 10, 32)
+	if err != nil {
+		return 0, 0, errors.Wrapf(err, "Invalid gid for user %s", name)
+	}
+	return uint32(uid), uint32(gid), nil
+}
+
+// lookupGroup returns the gid of a group, which can be a name or a gid
+func lookupGroup(name string) (uint32, error) {
+	g, err := user.LookupGroup(name)
+	if err != nil {
+		var idErr error
+		g, idErr = user.LookupGroupId(name)
+		if idErr != nil {
+			return 0, errors.Wrapf(err, "Fail to find the group %s", name)
+		}
+	}
+	gid, err := strconv.ParseUint(g.Gid, 10, 32)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Invalid gid for group %s", name)
+	}
+	return uint32(gid), nil
+}
+
+// buildSysProcAttr builds the process attributes of a command from its
+// configuration
+func buildSysProcAttr(config *CommandHealthcheckConfiguration) (*syscall.SysProcAttr, error) {
+	attr := &syscall.SysProcAttr{
+		Setpgid: true,
+	}
+	if config.User != "" {
+		uid, gid, err := lookupUser(config.User)
+		if err != nil {
+			return nil, err
+		}
+		if config.Group != "" {
+			gid, err = lookupGroup(config.Group)
+			if err != nil {
+				return nil, err
+			}
+		}
+		attr.Credential = &syscall.Credential{
+			Uid: uid,
+			Gid: gid,
+		}
+	}
+	err := isolate(attr, config)
+	if err != nil {
+		return nil, err
+	}
+	return attr, nil
+}
+
+// killProcessGroup kills a process and all processes in its group
+func killProcessGroup(process *os.Process) error {
+	err := syscall.Kill(-process.Pid, syscall.SIGKILL)
+	if err != nil {
+		return process.Kill()
+	}
+	return nil
+}
+
+// terminationStatus returns how a process terminated and the signal which
+// killed it, if any
+func terminationStatus(state *os.ProcessState) (string, string) {
+	status, ok := state.Sys().(syscall.WaitStatus)
+	if ok && status.Signaled() {
+		return "signaled", status.Signal().String()
+	}
+	return "exited", ""
+}
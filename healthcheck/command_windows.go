@@ -0,0 +1,51 @@
+//go:build windows
+
+package healthcheck
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// shellCommand returns the shell and its arguments used to execute a
+// command in shell mode
+func shellCommand(command string, arguments []string) (string, []string) {
+	return "cmd", append([]string{"/C", command}, arguments...)
+}
+
+// buildSysProcAttr builds the process attributes of a command from its
+// configuration
+func buildSysProcAttr(config *CommandHealthcheckConfiguration) (*syscall.SysProcAttr, error) {
+	if config.User != "" {
+		return nil, errors.New("Running a command as another user is not supported on Windows")
+	}
+	if config.NoNetwork {
+		return nil, errors.New("Disabling the network of a command is only supported on Linux")
+	}
+	if config.hasLimits() {
+		return nil, errors.New("Resource limits for commands are only supported on Linux")
+	}
+	return &syscall.SysProcAttr{}, nil
+}
+
+// killProcessGroup kills a process
+func killProcessGroup(process *os.Process) error {
+	return process.Kill()
+}
+
+// holdCommand makes a command wait for its resource limits before executing.
+// Resource limits are not supported on this platform.
+func holdCommand(cmd *exec.Cmd, config *CommandHealthcheckConfiguration) (func(process *os.Process) error, error) {
+	return func(process *os.Process) error {
+		return nil
+	}, nil
+}
+
+// terminationStatus returns how a process terminated and the signal which
+// killed it, if any
+func terminationStatus(state *os.ProcessState) (string, string) {
+	return "exited", ""
+}
@@ -1,5 +1,12 @@
 package healthcheck
 
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
 const (
 	// SourceConfig the check is managed by the configuration file
 	SourceConfig string = ""
@@ -17,6 +24,101 @@ type Base struct {
 	OneOff      bool              `json:"one-off"`
 	Source      string            `json:"source"`
 	Labels      map[string]string `json:"labels,omitempty"`
+	// inverts the healthcheck result: the healthcheck is successful
+	// only if its execution fails
+	ShouldFail bool `json:"should-fail" yaml:"should-fail"`
+	// the result is degraded if the execution takes longer than these
+	// durations
+	WarningDuration  Duration `json:"warning-duration,omitempty" yaml:"warning-duration,omitempty"`
+	CriticalDuration Duration `json:"critical-duration,omitempty" yaml:"critical-duration,omitempty"`
+	// failed executions are retried before reporting a failure, the delay
+	// between retries is doubled after each retry if backoff is enabled
+	Retries      uint     `json:"retries,omitempty"`
+	RetryDelay   Duration `json:"retry-delay,omitempty" yaml:"retry-delay,omitempty"`
+	RetryBackoff bool     `json:"retry-backoff,omitempty" yaml:"retry-backoff,omitempty"`
+	// the reported status becomes failed after fall consecutive failures,
+	// and successful after rise consecutive successes
+	Fall uint `json:"fall,omitempty"`
+	Rise uint `json:"rise,omitempty"`
+	// the healthcheck is flapping if its status changed at least
+	// flap-threshold times within the flap window
+	FlapThreshold uint     `json:"flap-threshold,omitempty" yaml:"flap-threshold,omitempty"`
+	FlapWindow    Duration `json:"flap-window,omitempty" yaml:"flap-window,omitempty"`
+	// interval used while the healthcheck is failing
+	FailureInterval Duration `json:"failure-interval,omitempty" yaml:"failure-interval,omitempty"`
+	// cron expression, alternative to the interval
+	Schedule string `json:"schedule,omitempty"`
+	// time zone of the schedule and of the active windows, local time
+	// by default
+	TimeZone      string         `json:"time-zone,omitempty" yaml:"time-zone,omitempty"`
+	ActiveWindows []ActiveWindow `json:"active-windows,omitempty" yaml:"active-windows,omitempty"`
+}
+
+// retryDelay returns the delay to wait before a retry, starting at 1
+func (b *Base) retryDelay(retry uint) time.Duration {
+	delay := time.Duration(b.RetryDelay)
+	if b.RetryBackoff {
+		delay = delay << (retry - 1)
+	}
+	return delay
+}
+
+// validate validates the configuration shared between healthchecks
+func (b *Base) validate() error {
+	if b.Name == "" {
+		return errors.New("The healthcheck name is missing")
+	}
+	if b.WarningDuration < 0 || b.CriticalDuration < 0 {
+		return errors.New("The healthcheck warning and critical durations should be positive")
+	}
+	if b.WarningDuration != 0 && b.CriticalDuration != 0 && b.WarningDuration >= b.CriticalDuration {
+		return errors.New("The healthcheck warning duration should be lower than the critical duration")
+	}
+	if b.RetryDelay < 0 {
+		return errors.New("The healthcheck retry delay should be positive")
+	}
+	if b.Retries > 10 {
+		return errors.New("The healthcheck retries should be lower than 11")
+	}
+	if !b.OneOff && b.FailureInterval != 0 && b.FailureInterval < Duration(2*time.Second) {
+		return errors.New("The healthcheck failure interval should be greater than 2 second")
+	}
+	if b.FlapThreshold != 0 && b.FlapWindow <= 0 {
+		return errors.New("The healthcheck flap window is missing")
+	}
+	err := b.validateSchedule()
+	if err != nil {
+		return err
+	}
+	if !b.OneOff && b.Retries != 0 && b.Interval != 0 {
+		total := time.Duration(0)
+		for retry := uint(1); retry <= b.Retries; retry++ {
+			total += b.retryDelay(retry)
+		}
+		if total >= time.Duration(b.Interval) {
+			return errors.New("The healthcheck retry delays should be lower than the interval")
+		}
+	}
+	return nil
+}
+
+// validateTimeout validates the configuration depending on the healthcheck
+// timeout
+func (b *Base) validateTimeout(timeout Duration) error {
+	if !b.OneOff && b.FailureInterval != 0 && b.FailureInterval < timeout {
+		return errors.New("The healthcheck failure interval should be greater than the timeout")
+	}
+	schedule, err := b.cronSchedule()
+	if err != nil {
+		return err
+	}
+	if schedule != nil {
+		gap := minScheduleGap(schedule, time.Now())
+		if gap != 0 && gap < time.Duration(timeout) {
+			return fmt.Errorf("The healthcheck schedule executions are %s apart, lower than the timeout", gap.String())
+		}
+	}
+	return nil
 }
 
 // SourceChecksNames returns all checks managed by the given source
@@ -43,6 +145,13 @@ func (in *Base) DeepCopyInto(out *Base) {
 			(*out)[key] = val
 		}
 	}
+	if in.ActiveWindows != nil {
+		in, out := &in.ActiveWindows, &out.ActiveWindows
+		*out = make([]ActiveWindow, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Base.
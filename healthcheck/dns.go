@@ -32,8 +32,8 @@ type DNSHealthcheck struct {
 
 // Validate validates the healthcheck configuration
 func (config *DNSHealthcheckConfiguration) Validate() error {
-	if config.Base.Name == "" {
-		return errors.New("The healthcheck name is missing")
+	if err := config.Base.validate(); err != nil {
+		return err
 	}
 	if config.Domain == "" {
 		return errors.New("The healthcheck domain is missing")
@@ -41,7 +41,10 @@ func (config *DNSHealthcheckConfiguration) Validate() error {
 	if config.Timeout == 0 {
 		return errors.New("The healthcheck timeout is missing")
 	}
-	if !config.Base.OneOff {
+	if err := config.Base.validateTimeout(config.Timeout); err != nil {
+		return err
+	}
+	if !config.Base.OneOff && config.Base.Schedule == "" {
 		if config.Base.Interval < Duration(2*time.Second) {
 			return errors.New("The healthcheck interval should be greater than 2 second")
 		}
@@ -72,6 +75,11 @@ func (h *DNSHealthcheck) SetSource(source string) {
 	h.Config.Base.Source = source
 }
 
+// Timeout returns the healthcheck timeout
+func (h *DNSHealthcheck) Timeout() time.Duration {
+	return time.Duration(h.Config.Timeout)
+}
+
 // Summary returns an healthcheck summary
 func (h *DNSHealthcheck) Summary() string {
 	summary := ""
@@ -135,9 +143,7 @@ func verifyIPs(expectedIPs []IP, lookupIPs []net.IP) error {
 	return nil
 }
 
-func (h *DNSHealthcheck) lookupIP() ([]net.IP, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(h.Config.Timeout))
-	defer cancel()
+func (h *DNSHealthcheck) lookupIP(ctx context.Context) ([]net.IP, error) {
 	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, h.Config.Domain)
 	if err != nil {
 		return nil, err
@@ -150,9 +156,9 @@ func (h *DNSHealthcheck) lookupIP() ([]net.IP, error) {
 }
 
 // Execute executes an healthcheck on the given domain
-func (h *DNSHealthcheck) Execute() error {
+func (h *DNSHealthcheck) Execute(ctx context.Context) error {
 	h.LogDebug("start executing healthcheck")
-	ips, err := h.lookupIP()
+	ips, err := h.lookupIP(ctx)
 	if err != nil {
 		return errors.Wrapf(err, "Fail to lookup IP for domain")
 	}
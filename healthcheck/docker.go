@@ -0,0 +1,343 @@
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const defaultDockerEndpoint = "unix:///var/run/docker.sock"
+
+// DockerHealthcheckConfiguration defines a Docker healthcheck configuration
+type DockerHealthcheckConfiguration struct {
+	Base `json:",inline" yaml:",inline"`
+	// unix:///path/to/socket or tcp://host:port
+	Endpoint string `json:"endpoint,omitempty"`
+	// containers selection
+	ContainerName   string            `json:"container-name,omitempty" yaml:"container-name,omitempty"`
+	ContainerLabels map[string]string `json:"container-labels,omitempty" yaml:"container-labels,omitempty"`
+	MaxRestarts     uint              `json:"max-restarts,omitempty" yaml:"max-restarts,omitempty"`
+	Timeout         Duration          `json:"timeout"`
+	// TLS options for tcp endpoints
+	TLS        bool   `json:"tls"`
+	Insecure   bool   `json:"insecure"`
+	ServerName string `json:"server-name"`
+	Key        string `json:"key,omitempty"`
+	Cert       string `json:"cert,omitempty"`
+	Cacert     string `json:"cacert,omitempty"`
+}
+
+// Validate validates the healthcheck configuration
+func (config *DockerHealthcheckConfiguration) Validate() error {
+	if err := config.Base.validate(); err != nil {
+		return err
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = defaultDockerEndpoint
+	}
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return errors.Wrapf(err, "Invalid healthcheck endpoint %s", config.Endpoint)
+	}
+	switch endpoint.Scheme {
+	case "unix":
+		if endpoint.Path == "" {
+			return errors.New("The healthcheck endpoint socket path is missing")
+		}
+		if config.TLS {
+			return errors.New("TLS is only supported for tcp endpoints")
+		}
+	case "tcp":
+		if endpoint.Host == "" {
+			return errors.New("The healthcheck endpoint host is missing")
+		}
+	default:
+		return fmt.Errorf("Invalid healthcheck endpoint scheme %s", endpoint.Scheme)
+	}
+	if config.ContainerName == "" && len(config.ContainerLabels) == 0 {
+		return errors.New("The healthcheck container name or labels are missing")
+	}
+	if config.Timeout == 0 {
+		return errors.New("The healthcheck timeout is missing")
+	}
+	if err := config.Base.validateTimeout(config.Timeout); err != nil {
+		return err
+	}
+	if !config.Base.OneOff && config.Base.Schedule == "" {
+		if config.Base.Interval < Duration(2*time.Second) {
+			return errors.New("The healthcheck interval should be greater than 2 second")
+		}
+		if config.Base.Interval < config.Timeout {
+			return errors.New("The healthcheck interval should be greater than the timeout")
+		}
+	}
+	if !((config.Key != "" && config.Cert != "") ||
+		(config.Key == "" && config.Cert == "")) {
+		return errors.New("Invalid certificates")
+	}
+	return nil
+}
+
+// DockerHealthcheck defines a Docker healthcheck
+type DockerHealthcheck struct {
+	Logger *zap.Logger
+	Config *DockerHealthcheckConfiguration
+	URL    string
+	Client *http.Client
+}
+
+// dockerContainer is a container returned by the container list endpoint
+type dockerContainer struct {
+	ID    string   `json:"Id"`
+	Names []string `json:"Names"`
+}
+
+// dockerContainerState is the container inspect endpoint response
+type dockerContainerState struct {
+	Name         string `json:"Name"`
+	RestartCount uint   `json:"RestartCount"`
+	State        struct {
+		Status  string `json:"Status"`
+		Running bool   `json:"Running"`
+		Health  *struct {
+			Status string `json:"Status"`
+		} `json:"Health"`
+	} `json:"State"`
+}
+
+// Summary returns an healthcheck summary
+func (h *DockerHealthcheck) Summary() string {
+	summary := ""
+	selector := h.Config.ContainerName
+	if selector == "" {
+		labels := make([]string, 0, len(h.Config.ContainerLabels))
+		for k, v := range h.Config.ContainerLabels {
+			labels = append(labels, fmt.Sprintf("%s=%s", k, v))
+		}
+		sort.Strings(labels)
+		selector = strings.Join(labels, ",")
+	}
+	if h.Config.Base.Description != "" {
+		summary = fmt.Sprintf("Docker healthcheck %s on containers %s", h.Config.Base.Description, selector)
+
+	} else {
+		summary = fmt.Sprintf("Docker healthcheck on containers %s", selector)
+	}
+
+	return summary
+}
+
+// Initialize the healthcheck.
+func (h *DockerHealthcheck) Initialize() error {
+	endpoint := h.Config.Endpoint
+	if endpoint == "" {
+		endpoint = defaultDockerEndpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return errors.Wrapf(err, "Invalid endpoint %s", endpoint)
+	}
+	client, err := newHTTPClient(nil, h.Config.Key, h.Config.Cert, h.Config.Cacert, h.Config.ServerName, h.Config.Insecure, false)
+	if err != nil {
+		return err
+	}
+	if u.Scheme == "unix" {
+		socket := u.Path
+		dialer := &net.Dialer{}
+		client.Transport.(*http.Transport).DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return dialer.DialContext(ctx, "unix", socket)
+		}
+		h.URL = "http://docker"
+	} else {
+		scheme := "http"
+		if h.Config.TLS {
+			scheme = "https"
+		}
+		h.URL = fmt.Sprintf("%s://%s", scheme, u.Host)
+	}
+	h.Client = client
+	return nil
+}
+
+// GetConfig get the config
+func (h *DockerHealthcheck) GetConfig() interface{} {
+	return h.Config
+}
+
+// Base get the base configuration
+func (h *DockerHealthcheck) Base() Base {
+	return h.Config.Base
+}
+
+// SetSource set the healthcheck source
+func (h *DockerHealthcheck) SetSource(source string) {
+	h.Config.Base.Source = source
+}
+
+// Timeout returns the healthcheck timeout
+func (h *DockerHealthcheck) Timeout() time.Duration {
+	return time.Duration(h.Config.Timeout)
+}
+
+// LogError logs an error with context
+func (h *DockerHealthcheck) LogError(err error, message string) {
+	h.Logger.Error(err.Error(),
+		zap.String("extra", message),
+		zap.String("endpoint", h.Config.Endpoint),
+		zap.String("name", h.Config.Base.Name))
+}
+
+// LogDebug logs a message with context
+func (h *DockerHealthcheck) LogDebug(message string) {
+	h.Logger.Debug(message,
+		zap.String("endpoint", h.Config.Endpoint),
+		zap.String("name", h.Config.Base.Name))
+}
+
+// LogInfo logs a message with context
+func (h *DockerHealthcheck) LogInfo(message string) {
+	h.Logger.Info(message,
+		zap.String("endpoint", h.Config.Endpoint),
+		zap.String("name", h.Config.Base.Name))
+}
+
+// get executes a request on the Engine API and decodes the response
+func (h *DockerHealthcheck) get(ctx context.Context, path string, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", h.URL+path, nil)
+	if err != nil {
+		return errors.Wrapf(err, "fail to initialize HTTP request")
+	}
+	req.Header.Set("User-Agent", "Cabourotte")
+	response, err := h.Client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "Docker API request failed")
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("Docker API request failed: status %d", response.StatusCode)
+	}
+	err = json.NewDecoder(response.Body).Decode(result)
+	if err != nil {
+		return errors.Wrapf(err, "Fail to decode the Docker API response")
+	}
+	return nil
+}
+
+// listPath returns the container list path with the selection filters
+func (h *DockerHealthcheck) listPath() (string, error) {
+	filters := map[string][]string{}
+	if h.Config.ContainerName != "" {
+		filters["name"] = []string{fmt.Sprintf("^/%s$", regexp.QuoteMeta(h.Config.ContainerName))}
+	}
+	for k, v := range h.Config.ContainerLabels {
+		filters["label"] = append(filters["label"], fmt.Sprintf("%s=%s", k, v))
+	}
+	encoded, err := json.Marshal(filters)
+	if err != nil {
+		return "", errors.Wrapf(err, "Fail to encode the containers filters")
+	}
+	return fmt.Sprintf("/containers/json?all=true&filters=%s", url.QueryEscape(string(encoded))), nil
+}
+
+// Execute executes an healthcheck on the given target
+func (h *DockerHealthcheck) Execute(ctx context.Context) error {
+	_, err := h.ExecuteWithDetails(ctx)
+	return err
+}
+
+// ExecuteWithDetails executes the healthcheck, returning the state, health
+// status and restart count of each container as details
+func (h *DockerHealthcheck) ExecuteWithDetails(ctx context.Context) (map[string]interface{}, error) {
+	h.LogDebug("start executing healthcheck")
+	path, err := h.listPath()
+	if err != nil {
+		return nil, err
+	}
+	var containers []dockerContainer
+	err = h.get(ctx, path, &containers)
+	if err != nil {
+		return nil, err
+	}
+	if len(containers) == 0 {
+		return nil, errors.New("No container found")
+	}
+	states := map[string]interface{}{}
+	details := map[string]interface{}{
+		"containers": states,
+	}
+	var failure error
+	for _, container := range containers {
+		var state dockerContainerState
+		err = h.get(ctx, fmt.Sprintf("/containers/%s/json", container.ID), &state)
+		if err != nil {
+			return details, err
+		}
+		name := strings.TrimPrefix(state.Name, "/")
+		health := ""
+		if state.State.Health != nil {
+			health = state.State.Health.Status
+		}
+		states[name] = map[string]interface{}{
+			"status":        state.State.Status,
+			"health":        health,
+			"restart-count": state.RestartCount,
+		}
+		if failure != nil {
+			continue
+		}
+		if !state.State.Running || state.State.Status == "restarting" {
+			failure = fmt.Errorf("The container %s is %s", name, state.State.Status)
+		} else if health == "unhealthy" {
+			failure = fmt.Errorf("The container %s is unhealthy", name)
+		} else if h.Config.MaxRestarts != 0 && state.RestartCount > h.Config.MaxRestarts {
+			failure = fmt.Errorf("The container %s restarted %d times, more than %d", name, state.RestartCount, h.Config.MaxRestarts)
+		}
+	}
+	return details, failure
+}
+
+// NewDockerHealthcheck creates a Docker healthcheck from a logger and a configuration
+func NewDockerHealthcheck(logger *zap.Logger, config *DockerHealthcheckConfiguration) *DockerHealthcheck {
+	return &DockerHealthcheck{
+		Logger: logger,
+		Config: config,
+	}
+}
+
+// MarshalJSON marshal to json a Docker healthcheck
+func (h *DockerHealthcheck) MarshalJSON() ([]byte, error) {
+	return json.Marshal(h.Config)
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DockerHealthcheckConfiguration) DeepCopyInto(out *DockerHealthcheckConfiguration) {
+	*out = *in
+	in.Base.DeepCopyInto(&out.Base)
+	if in.ContainerLabels != nil {
+		in, out := &in.ContainerLabels, &out.ContainerLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DockerHealthcheckConfiguration.
+func (in *DockerHealthcheckConfiguration) DeepCopy() *DockerHealthcheckConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(DockerHealthcheckConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
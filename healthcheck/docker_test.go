@@ -0,0 +1,181 @@
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v2"
+)
+
+// dockerTestServer starts an Engine API stand-in on a unix socket
+func dockerTestServer(t *testing.T, containers map[string]dockerContainerState) (*httptest.Server, string) {
+	socket := filepath.Join(t.TempDir(), "docker.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("Fail to listen on the unix socket :\n%v", err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/containers/json", func(w http.ResponseWriter, r *http.Request) {
+		filters := map[string][]string{}
+		err := json.Unmarshal([]byte(r.URL.Query().Get("filters")), &filters)
+		if err != nil || r.URL.Query().Get("all") != "true" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		result := []dockerContainer{}
+		for id, container := range containers {
+			// the Engine API matches the names as regular expressions
+			if len(filters["name"]) != 0 {
+				matched, err := regexp.MatchString(filters["name"][0], container.Name)
+				if err != nil || !matched {
+					continue
+				}
+			}
+			result = append(result, dockerContainer{ID: id, Names: []string{container.Name}})
+		}
+		_ = json.NewEncoder(w).Encode(result)
+	})
+	mux.HandleFunc("/containers/{id}/json", func(w http.ResponseWriter, r *http.Request) {
+		container, ok := containers[r.PathValue("id")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(container)
+	})
+	server := httptest.NewUnstartedServer(mux)
+	server.Listener = listener
+	server.Start()
+	return server, fmt.Sprintf("unix://%s", socket)
+}
+
+func dockerTestContainer(name string, status string, health string, restarts uint) dockerContainerState {
+	container := dockerContainerState{Name: "/" + name, RestartCount: restarts}
+	container.State.Status = status
+	container.State.Running = status == "running"
+	if health != "" {
+		container.State.Health = &struct {
+			Status string `json:"Status"`
+		}{Status: health}
+	}
+	return container
+}
+
+func TestDockerExecute(t *testing.T) {
+	containers := map[string]dockerContainerState{
+		"1": dockerTestContainer("web", "running", "healthy", 0),
+		"2": dockerTestContainer("worker", "running", "", 5),
+		"3": dockerTestContainer("db", "running", "unhealthy", 0),
+		"4": dockerTestContainer("cron", "exited", "", 0),
+		"5": dockerTestContainer("api.1", "running", "", 0),
+		"6": dockerTestContainer("api-1", "exited", "", 0),
+	}
+	server, endpoint := dockerTestServer(t, containers)
+	defer server.Close()
+	cases := []struct {
+		name        string
+		maxRestarts uint
+		fail        bool
+	}{
+		{name: "web", fail: false},
+		{name: "worker", fail: false},
+		{name: "worker", maxRestarts: 3, fail: true},
+		{name: "db", fail: true},
+		{name: "cron", fail: true},
+		{name: "unknown", fail: true},
+		// the dot in the name is not a wildcard
+		{name: "api.1", fail: false},
+	}
+	for _, c := range cases {
+		h := NewDockerHealthcheck(
+			zap.NewExample(),
+			&DockerHealthcheckConfiguration{
+				Endpoint:      endpoint,
+				ContainerName: c.name,
+				MaxRestarts:   c.maxRestarts,
+				Timeout:       Duration(time.Second * 2),
+			})
+		err := h.Initialize()
+		if err != nil {
+			t.Fatalf("Initialization error :\n%v", err)
+		}
+		details, err := h.ExecuteWithDetails(context.Background())
+		if c.fail && err == nil {
+			t.Fatalf("healthcheck was expected to fail for %s", c.name)
+		}
+		if !c.fail && err != nil {
+			t.Fatalf("healthcheck error for %s :\n%v", c.name, err)
+		}
+		if c.name == "web" {
+			state := details["containers"].(map[string]interface{})["web"].(map[string]interface{})
+			if state["health"] != "healthy" || state["status"] != "running" {
+				t.Fatalf("Invalid details: %v", details)
+			}
+		}
+	}
+}
+
+func TestDockerValidate(t *testing.T) {
+	cases := []DockerHealthcheckConfiguration{
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Timeout: Duration(time.Second)},
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, ContainerName: "web"},
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Endpoint: "http://127.0.0.1:2375", ContainerName: "web", Timeout: Duration(time.Second)},
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Endpoint: "unix:///var/run/docker.sock", TLS: true, ContainerName: "web", Timeout: Duration(time.Second)},
+	}
+	for i := range cases {
+		err := cases[i].Validate()
+		if err == nil {
+			t.Fatalf("Validation was expected to fail for case %d", i)
+		}
+	}
+	valid := DockerHealthcheckConfiguration{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, ContainerLabels: map[string]string{"app": "web"}, Timeout: Duration(time.Second)}
+	err := valid.Validate()
+	if err != nil {
+		t.Fatalf("Validation error :\n%v", err)
+	}
+	if valid.Endpoint != defaultDockerEndpoint {
+		t.Fatalf("Invalid default endpoint %s", valid.Endpoint)
+	}
+}
+
+func TestDockerUnmarshalSelectors(t *testing.T) {
+	yamlConfig := `
+name: foo
+labels:
+  env: prod
+container-name: web
+container-labels:
+  app: web
+`
+	jsonConfig := `{"name":"foo","labels":{"env":"prod"},"container-name":"web","container-labels":{"app":"web"}}`
+	var fromYAML DockerHealthcheckConfiguration
+	err := yaml.UnmarshalStrict([]byte(yamlConfig), &fromYAML)
+	if err != nil {
+		t.Fatalf("Unmarshal error :\n%v", err)
+	}
+	var fromJSON DockerHealthcheckConfiguration
+	err = json.Unmarshal([]byte(jsonConfig), &fromJSON)
+	if err != nil {
+		t.Fatalf("Unmarshal error :\n%v", err)
+	}
+	for _, config := range []DockerHealthcheckConfiguration{fromYAML, fromJSON} {
+		if config.Base.Name != "foo" || config.ContainerName != "web" {
+			t.Fatalf("Invalid names: %s %s", config.Base.Name, config.ContainerName)
+		}
+		if config.Base.Labels["env"] != "prod" || len(config.Base.Labels) != 1 {
+			t.Fatalf("Invalid healthcheck labels: %v", config.Base.Labels)
+		}
+		if config.ContainerLabels["app"] != "web" || len(config.ContainerLabels) != 1 {
+			t.Fatalf("Invalid container labels: %v", config.ContainerLabels)
+		}
+	}
+}
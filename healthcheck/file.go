@@ -0,0 +1,339 @@
+package healthcheck
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// defaultContentLimit the default maximum size of the file content matched
+// against the regexps
+const defaultContentLimit = 1024 * 1024
+
+// FileHealthcheckConfiguration defines a file healthcheck configuration
+type FileHealthcheckConfiguration struct {
+	Base `json:",inline" yaml:",inline"`
+	// can be a glob
+	Path          string   `json:"path"`
+	Absent        bool     `json:"absent"`
+	MinMatches    uint     `json:"min-matches,omitempty" yaml:"min-matches,omitempty"`
+	MaxAge        Duration `json:"max-age,omitempty" yaml:"max-age,omitempty"`
+	MinSize       int64    `json:"min-size,omitempty" yaml:"min-size,omitempty"`
+	MaxSize       int64    `json:"max-size,omitempty" yaml:"max-size,omitempty"`
+	Owner         string   `json:"owner,omitempty"`
+	Mode          string   `json:"mode,omitempty"`
+	ContentRegexp []Regexp `json:"content-regexp,omitempty" yaml:"content-regexp,omitempty"`
+	// the regexps are matched against the first bytes of the files
+	ContentLimit uint     `json:"content-limit,omitempty" yaml:"content-limit,omitempty"`
+	SHA256       string   `json:"sha256,omitempty"`
+	Timeout      Duration `json:"timeout"`
+}
+
+// FileHealthcheck defines a file healthcheck
+type FileHealthcheck struct {
+	Logger *zap.Logger
+	Config *FileHealthcheckConfiguration
+
+	mode os.FileMode
+}
+
+// Validate validates the healthcheck configuration
+func (config *FileHealthcheckConfiguration) Validate() error {
+	if err := config.Base.validate(); err != nil {
+		return err
+	}
+	if config.Path == "" {
+		return errors.New("The healthcheck path is missing")
+	}
+	if _, err := filepath.Match(config.Path, ""); err != nil {
+		return errors.Wrapf(err, "Invalid healthcheck path %s", config.Path)
+	}
+	if config.Timeout == 0 {
+		return errors.New("The healthcheck timeout is missing")
+	}
+	if config.MaxSize != 0 && config.MaxSize < config.MinSize {
+		return errors.New("The healthcheck max size should be greater than the min size")
+	}
+	if config.Mode != "" {
+		mode, err := strconv.ParseUint(config.Mode, 8, 32)
+		if err != nil || mode > 0777 {
+			return fmt.Errorf("Invalid healthcheck file mode %s", config.Mode)
+		}
+	}
+	if config.SHA256 != "" {
+		hash, err := hex.DecodeString(config.SHA256)
+		if err != nil || len(hash) != sha256.Size {
+			return fmt.Errorf("Invalid healthcheck sha256 %s", config.SHA256)
+		}
+	}
+	if err := config.Base.validateTimeout(config.Timeout); err != nil {
+		return err
+	}
+	if !config.Base.OneOff && config.Base.Schedule == "" {
+		if config.Base.Interval < Duration(2*time.Second) {
+			return errors.New("The healthcheck interval should be greater than 2 second")
+		}
+		if config.Base.Interval < config.Timeout {
+			return errors.New("The healthcheck interval should be greater than the timeout")
+		}
+	}
+	return nil
+}
+
+// Initialize the healthcheck.
+func (h *FileHealthcheck) Initialize() error {
+	if h.Config.Mode != "" {
+		mode, err := strconv.ParseUint(h.Config.Mode, 8, 32)
+		if err != nil {
+			return errors.Wrapf(err, "Invalid file mode %s", h.Config.Mode)
+		}
+		h.mode = os.FileMode(mode)
+	}
+	return nil
+}
+
+// GetConfig get the config
+func (h *FileHealthcheck) GetConfig() interface{} {
+	return h.Config
+}
+
+// Base get the base configuration
+func (h *FileHealthcheck) Base() Base {
+	return h.Config.Base
+}
+
+// SetSource set the healthcheck source
+func (h *FileHealthcheck) SetSource(source string) {
+	h.Config.Base.Source = source
+}
+
+// Timeout returns the healthcheck timeout
+func (h *FileHealthcheck) Timeout() time.Duration {
+	return time.Duration(h.Config.Timeout)
+}
+
+// Summary returns an healthcheck summary
+func (h *FileHealthcheck) Summary() string {
+	summary := ""
+	if h.Config.Base.Description != "" {
+		summary = fmt.Sprintf("file healthcheck %s on %s", h.Config.Base.Description, h.Config.Path)
+
+	} else {
+		summary = fmt.Sprintf("file healthcheck on %s", h.Config.Path)
+	}
+
+	return summary
+}
+
+// LogError logs an error with context
+func (h *FileHealthcheck) LogError(err error, message string) {
+	h.Logger.Error(err.Error(),
+		zap.String("extra", message),
+		zap.String("path", h.Config.Path),
+		zap.String("name", h.Config.Base.Name))
+}
+
+// LogDebug logs a message with context
+func (h *FileHealthcheck) LogDebug(message string) {
+	h.Logger.Debug(message,
+		zap.String("path", h.Config.Path),
+		zap.String("name", h.Config.Base.Name))
+}
+
+// LogInfo logs a message with context
+func (h *FileHealthcheck) LogInfo(message string) {
+	h.Logger.Info(message,
+		zap.String("path", h.Config.Path),
+		zap.String("name", h.Config.Base.Name))
+}
+
+// contextReader is a reader failing once its context is done
+type contextReader struct {
+	ctx    context.Context
+	reader io.Reader
+}
+
+// Read reads from the underlying reader if the context is not done
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.reader.Read(p)
+}
+
+// verifyContent verifies the content of a file against the configured
+// regexps and hash
+func (h *FileHealthcheck) verifyContent(ctx context.Context, path string) error {
+	if len(h.Config.ContentRegexp) == 0 && h.Config.SHA256 == "" {
+		return nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return errors.Wrapf(err, "Fail to open %s", path)
+	}
+	defer file.Close()
+	limit := int(h.Config.ContentLimit)
+	if limit == 0 {
+		limit = defaultContentLimit
+	}
+	hash := sha256.New()
+	content := &limitedBuffer{limit: limit}
+	var reader io.Reader = &contextReader{ctx: ctx, reader: file}
+	var writer io.Writer = hash
+	if len(h.Config.ContentRegexp) != 0 {
+		writer = io.MultiWriter(hash, content)
+	}
+	if h.Config.SHA256 == "" {
+		// the file is only read up to the limit if the hash is not needed
+		reader = io.LimitReader(reader, int64(limit))
+	}
+	_, err = io.Copy(writer, reader)
+	if err != nil {
+		return errors.Wrapf(err, "Fail to read %s", path)
+	}
+	for _, regex := range h.Config.ContentRegexp {
+		r := regexp.Regexp(regex)
+		if !r.MatchString(content.String()) {
+			return fmt.Errorf("The content of %s does not match regex %s", path, r.String())
+		}
+	}
+	if h.Config.SHA256 != "" {
+		sum := hex.EncodeToString(hash.Sum(nil))
+		if !strings.EqualFold(sum, h.Config.SHA256) {
+			return fmt.Errorf("The sha256 of %s is %s", path, sum)
+		}
+	}
+	return nil
+}
+
+// verifyFile verifies a file against the healthcheck assertions
+func (h *FileHealthcheck) verifyFile(ctx context.Context, path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return errors.Wrapf(err, "Fail to read the file information for %s", path)
+	}
+	if h.Config.MaxAge != 0 {
+		age := time.Since(info.ModTime())
+		if age > time.Duration(h.Config.MaxAge) {
+			return fmt.Errorf("The file %s was modified %s ago", path, age.Truncate(time.Second).String())
+		}
+	}
+	if h.Config.MinSize != 0 && info.Size() < h.Config.MinSize {
+		return fmt.Errorf("The size of %s is %d bytes, lower than %d", path, info.Size(), h.Config.MinSize)
+	}
+	if h.Config.MaxSize != 0 && info.Size() > h.Config.MaxSize {
+		return fmt.Errorf("The size of %s is %d bytes, greater than %d", path, info.Size(), h.Config.MaxSize)
+	}
+	if h.Config.Mode != "" && info.Mode().Perm() != h.mode {
+		return fmt.Errorf("The mode of %s is %#o", path, info.Mode().Perm())
+	}
+	if h.Config.Owner != "" {
+		err := verifyOwner(info, h.Config.Owner)
+		if err != nil {
+			return errors.Wrapf(err, "Invalid owner for %s", path)
+		}
+	}
+	if !info.IsDir() {
+		return h.verifyContent(ctx, path)
+	}
+	return nil
+}
+
+// Execute executes an healthcheck on the given path
+func (h *FileHealthcheck) Execute(ctx context.Context) error {
+	_, err := h.ExecuteWithDetails(ctx)
+	return err
+}
+
+// ExecuteWithDetails executes the healthcheck, returning the number of
+// files matching the path and satisfying the assertions as details
+func (h *FileHealthcheck) ExecuteWithDetails(ctx context.Context) (map[string]interface{}, error) {
+	h.LogDebug("start executing healthcheck")
+	paths, err := filepath.Glob(h.Config.Path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Fail to list files for %s", h.Config.Path)
+	}
+	details := map[string]interface{}{
+		"matches": len(paths),
+	}
+	if h.Config.Absent {
+		if len(paths) != 0 {
+			return details, fmt.Errorf("%d files match %s but none was expected", len(paths), h.Config.Path)
+		}
+		return details, nil
+	}
+	minMatches := h.Config.MinMatches
+	if minMatches == 0 {
+		minMatches = 1
+	}
+	valid := uint(0)
+	var fileErr error
+	for _, path := range paths {
+		if ctx.Err() != nil {
+			return details, errors.Wrapf(ctx.Err(), "Fail to verify the files matching %s", h.Config.Path)
+		}
+		err := h.verifyFile(ctx, path)
+		if err != nil {
+			fileErr = err
+			continue
+		}
+		valid++
+	}
+	details["valid"] = valid
+	if valid < minMatches {
+		msg := fmt.Sprintf("%d files matching %s satisfy the healthcheck, %d expected", valid, h.Config.Path, minMatches)
+		if fileErr != nil {
+			return details, errors.Wrap(fileErr, msg)
+		}
+		return details, errors.New(msg)
+	}
+	return details, nil
+}
+
+// NewFileHealthcheck creates a file healthcheck from a logger and a configuration
+func NewFileHealthcheck(logger *zap.Logger, config *FileHealthcheckConfiguration) *FileHealthcheck {
+	return &FileHealthcheck{
+		Logger: logger,
+		Config: config,
+	}
+}
+
+// MarshalJSON marshal to json a file healthcheck
+func (h *FileHealthcheck) MarshalJSON() ([]byte, error) {
+	return json.Marshal(h.Config)
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FileHealthcheckConfiguration) DeepCopyInto(out *FileHealthcheckConfiguration) {
+	*out = *in
+	in.Base.DeepCopyInto(&out.Base)
+	if in.ContentRegexp != nil {
+		in, out := &in.ContentRegexp, &out.ContentRegexp
+		*out = make([]Regexp, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FileHealthcheckConfiguration.
+func (in *FileHealthcheckConfiguration) DeepCopy() *FileHealthcheckConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(FileHealthcheckConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
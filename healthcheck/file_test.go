@@ -0,0 +1,159 @@
+package healthcheck
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestFileExecuteSuccess(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "report.txt"), []byte("status: done"), 0640)
+	if err != nil {
+		t.Fatalf("Fail to write the file :\n%v", err)
+	}
+	r := regexp.MustCompile("status: done")
+	h := NewFileHealthcheck(
+		zap.NewExample(),
+		&FileHealthcheckConfiguration{
+			Path:          filepath.Join(dir, "*.txt"),
+			MaxAge:        Duration(time.Minute),
+			MinSize:       5,
+			MaxSize:       100,
+			Mode:          "0640",
+			ContentRegexp: []Regexp{Regexp(*r)},
+			SHA256:        "0000000000000000000000000000000000000000000000000000000000000000",
+			Timeout:       Duration(time.Second * 2),
+		})
+	err = h.Initialize()
+	if err != nil {
+		t.Fatalf("Fail to initialize the healthcheck :\n%v", err)
+	}
+	// wrong hash
+	details, err := h.ExecuteWithDetails(context.Background())
+	if err == nil {
+		t.Fatalf("healthcheck was expected to fail")
+	}
+	if details["matches"] != 1 || details["valid"] != uint(0) {
+		t.Fatalf("Invalid details: %v", details)
+	}
+	h.Config.SHA256 = "0e6d66429e8615493c25e11249f31491b031c4e129876685bec6697d05126dc6"
+	err = h.Execute(context.Background())
+	if err != nil {
+		t.Fatalf("healthcheck error :\n%v", err)
+	}
+}
+
+func TestFileExecuteContentLimit(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report.txt")
+	err := os.WriteFile(path, []byte("status: pending\nstatus: done"), 0640)
+	if err != nil {
+		t.Fatalf("Fail to write the file :\n%v", err)
+	}
+	r := regexp.MustCompile("status: done")
+	h := NewFileHealthcheck(
+		zap.NewExample(),
+		&FileHealthcheckConfiguration{
+			Path:          path,
+			ContentRegexp: []Regexp{Regexp(*r)},
+			ContentLimit:  15,
+			Timeout:       Duration(time.Second * 2),
+		})
+	err = h.Initialize()
+	if err != nil {
+		t.Fatalf("Fail to initialize the healthcheck :\n%v", err)
+	}
+	err = h.Execute(context.Background())
+	if err == nil {
+		t.Fatalf("healthcheck was expected to fail")
+	}
+	h.Config.ContentLimit = 0
+	err = h.Execute(context.Background())
+	if err != nil {
+		t.Fatalf("healthcheck error :\n%v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = h.Execute(ctx)
+	if err == nil {
+		t.Fatalf("healthcheck was expected to fail")
+	}
+}
+
+func TestFileExecuteFailure(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "marker")
+	err := os.WriteFile(path, []byte("foo"), 0600)
+	if err != nil {
+		t.Fatalf("Fail to write the file :\n%v", err)
+	}
+	cases := []FileHealthcheckConfiguration{
+		{Path: path, MaxSize: 2},
+		{Path: path, MinSize: 10},
+		{Path: path, Mode: "0644"},
+		{Path: path, Absent: true},
+		{Path: filepath.Join(dir, "doesnotexist")},
+		{Path: filepath.Join(dir, "*"), MinMatches: 2},
+	}
+	for i := range cases {
+		config := cases[i]
+		config.Timeout = Duration(time.Second * 2)
+		h := NewFileHealthcheck(zap.NewExample(), &config)
+		err := h.Initialize()
+		if err != nil {
+			t.Fatalf("Fail to initialize the healthcheck :\n%v", err)
+		}
+		err = h.Execute(context.Background())
+		if err == nil {
+			t.Fatalf("healthcheck was expected to fail for case %d", i)
+		}
+	}
+	old := time.Now().Add(-time.Hour)
+	err = os.Chtimes(path, old, old)
+	if err != nil {
+		t.Fatalf("Fail to change the file modification time :\n%v", err)
+	}
+	h := NewFileHealthcheck(zap.NewExample(), &FileHealthcheckConfiguration{
+		Path:    path,
+		MaxAge:  Duration(time.Minute),
+		Timeout: Duration(time.Second * 2),
+	})
+	err = h.Execute(context.Background())
+	if err == nil {
+		t.Fatalf("healthcheck was expected to fail")
+	}
+}
+
+func TestFileExecuteAbsent(t *testing.T) {
+	h := NewFileHealthcheck(zap.NewExample(), &FileHealthcheckConfiguration{
+		Path:    filepath.Join(t.TempDir(), "*.lock"),
+		Absent:  true,
+		Timeout: Duration(time.Second * 2),
+	})
+	err := h.Execute(context.Background())
+	if err != nil {
+		t.Fatalf("healthcheck error :\n%v", err)
+	}
+}
+
+func TestFileValidate(t *testing.T) {
+	cases := []FileHealthcheckConfiguration{
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Timeout: Duration(time.Second)},
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Path: "/tmp/foo", Timeout: Duration(time.Second), Mode: "999"},
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Path: "/tmp/foo", Timeout: Duration(time.Second), SHA256: "abc"},
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Path: "/tmp/foo", Timeout: Duration(time.Second), MinSize: 10, MaxSize: 5},
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Path: "/tmp/[", Timeout: Duration(time.Second)},
+	}
+	for i := range cases {
+		err := cases[i].Validate()
+		if err == nil {
+			t.Fatalf("Validation was expected to fail for case %d", i)
+		}
+	}
+}
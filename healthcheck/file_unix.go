@@ -0,0 +1,26 @@
+//go:build !windows
+
+package healthcheck
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// verifyOwner verifies that a file is owned by an user, which can be a
+// name or an uid
+func verifyOwner(info os.FileInfo, owner string) error {
+	uid, _, err := lookupUser(owner)
+	if err != nil {
+		return err
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fmt.Errorf("Fail to read the owner of %s", info.Name())
+	}
+	if stat.Uid != uid {
+		return fmt.Errorf("The file is owned by uid %d, expected %d", stat.Uid, uid)
+	}
+	return nil
+}
@@ -0,0 +1,305 @@
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/procfs"
+	"go.uber.org/zap"
+)
+
+// Threshold defines the warning and critical thresholds for a measured
+// value. A zero value disables the threshold.
+type Threshold struct {
+	Warning  float64 `json:"warning,omitempty"`
+	Critical float64 `json:"critical,omitempty"`
+}
+
+// isSet returns true if at least one of the thresholds is configured
+func (t Threshold) isSet() bool {
+	return t.Warning != 0 || t.Critical != 0
+}
+
+// above returns the status of a value for which higher is worse
+func (t Threshold) above(value float64) Status {
+	if t.Critical != 0 && value >= t.Critical {
+		return StatusCritical
+	}
+	if t.Warning != 0 && value >= t.Warning {
+		return StatusWarning
+	}
+	return ""
+}
+
+// below returns the status of a value for which lower is worse
+func (t Threshold) below(value float64) Status {
+	if t.Critical != 0 && value <= t.Critical {
+		return StatusCritical
+	}
+	if t.Warning != 0 && value <= t.Warning {
+		return StatusWarning
+	}
+	return ""
+}
+
+// validatePercent validates a threshold expressed as a percentage
+func (t Threshold) validatePercent(name string, lowerIsWorse bool) error {
+	if t.Warning < 0 || t.Warning > 100 || t.Critical < 0 || t.Critical > 100 {
+		return fmt.Errorf("The healthcheck %s thresholds should be between 0 and 100", name)
+	}
+	if t.Warning != 0 && t.Critical != 0 {
+		if lowerIsWorse && t.Warning < t.Critical {
+			return fmt.Errorf("The healthcheck %s warning threshold should be greater than the critical threshold", name)
+		}
+		if !lowerIsWorse && t.Warning > t.Critical {
+			return fmt.Errorf("The healthcheck %s warning threshold should be lower than the critical threshold", name)
+		}
+	}
+	return nil
+}
+
+// HostHealthcheckConfiguration defines a host healthcheck configuration
+type HostHealthcheckConfiguration struct {
+	Base `json:",inline" yaml:",inline"`
+	// mount points to verify, default to /
+	Mounts []string `json:"mounts,omitempty"`
+	// usage percentages
+	DiskUsage  Threshold `json:"disk-usage,omitempty" yaml:"disk-usage,omitempty"`
+	InodeUsage Threshold `json:"inode-usage,omitempty" yaml:"inode-usage,omitempty"`
+	// available percentages
+	MemoryAvailable Threshold `json:"memory-available,omitempty" yaml:"memory-available,omitempty"`
+	SwapAvailable   Threshold `json:"swap-available,omitempty" yaml:"swap-available,omitempty"`
+	// 1 minute load average divided by the number of CPUs
+	LoadPerCPU Threshold `json:"load-per-cpu,omitempty" yaml:"load-per-cpu,omitempty"`
+	Timeout    Duration  `json:"timeout"`
+}
+
+// HostHealthcheck defines a host healthcheck
+type HostHealthcheck struct {
+	Logger *zap.Logger
+	Config *HostHealthcheckConfiguration
+
+	fs procfs.FS
+	// mount points to verify, the configured ones or /
+	mounts []string
+}
+
+// Validate validates the healthcheck configuration
+func (config *HostHealthcheckConfiguration) Validate() error {
+	if err := config.Base.validate(); err != nil {
+		return err
+	}
+	if !config.DiskUsage.isSet() && !config.InodeUsage.isSet() && !config.MemoryAvailable.isSet() && !config.SwapAvailable.isSet() && !config.LoadPerCPU.isSet() {
+		return errors.New("The healthcheck should have at least one threshold")
+	}
+	if err := config.DiskUsage.validatePercent("disk usage", false); err != nil {
+		return err
+	}
+	if err := config.InodeUsage.validatePercent("inode usage", false); err != nil {
+		return err
+	}
+	if err := config.MemoryAvailable.validatePercent("memory available", true); err != nil {
+		return err
+	}
+	if err := config.SwapAvailable.validatePercent("swap available", true); err != nil {
+		return err
+	}
+	if config.LoadPerCPU.Warning < 0 || config.LoadPerCPU.Critical < 0 {
+		return errors.New("The healthcheck load per CPU thresholds should be positive")
+	}
+	if config.LoadPerCPU.Warning != 0 && config.LoadPerCPU.Critical != 0 && config.LoadPerCPU.Warning > config.LoadPerCPU.Critical {
+		return errors.New("The healthcheck load per CPU warning threshold should be lower than the critical threshold")
+	}
+	if config.Timeout == 0 {
+		return errors.New("The healthcheck timeout is missing")
+	}
+	if err := config.Base.validateTimeout(config.Timeout); err != nil {
+		return err
+	}
+	if !config.Base.OneOff && config.Base.Schedule == "" {
+		if config.Base.Interval < Duration(2*time.Second) {
+			return errors.New("The healthcheck interval should be greater than 2 second")
+		}
+		if config.Base.Interval < config.Timeout {
+			return errors.New("The healthcheck interval should be greater than the timeout")
+		}
+	}
+	return nil
+}
+
+// Initialize the healthcheck.
+func (h *HostHealthcheck) Initialize() error {
+	h.mounts = h.Config.Mounts
+	if len(h.mounts) == 0 {
+		h.mounts = []string{"/"}
+	}
+	fs, err := procfs.NewFS(procfs.DefaultMountPoint)
+	if err != nil {
+		return errors.Wrapf(err, "Fail to read %s", procfs.DefaultMountPoint)
+	}
+	h.fs = fs
+	return nil
+}
+
+// GetConfig get the config
+func (h *HostHealthcheck) GetConfig() interface{} {
+	return h.Config
+}
+
+// Base get the base configuration
+func (h *HostHealthcheck) Base() Base {
+	return h.Config.Base
+}
+
+// SetSource set the healthcheck source
+func (h *HostHealthcheck) SetSource(source string) {
+	h.Config.Base.Source = source
+}
+
+// Timeout returns the healthcheck timeout
+func (h *HostHealthcheck) Timeout() time.Duration {
+	return time.Duration(h.Config.Timeout)
+}
+
+// Summary returns an healthcheck summary
+func (h *HostHealthcheck) Summary() string {
+	summary := ""
+	if h.Config.Base.Description != "" {
+		summary = fmt.Sprintf("host healthcheck %s", h.Config.Base.Description)
+
+	} else {
+		summary = "host healthcheck"
+	}
+
+	return summary
+}
+
+// LogError logs an error with context
+func (h *HostHealthcheck) LogError(err error, message string) {
+	h.Logger.Error(err.Error(),
+		zap.String("extra", message),
+		zap.String("name", h.Config.Base.Name))
+}
+
+// LogDebug logs a message with context
+func (h *HostHealthcheck) LogDebug(message string) {
+	h.Logger.Debug(message,
+		zap.String("name", h.Config.Base.Name))
+}
+
+// LogInfo logs a message with context
+func (h *HostHealthcheck) LogInfo(message string) {
+	h.Logger.Info(message,
+		zap.String("name", h.Config.Base.Name))
+}
+
+// hostReport accumulates the warnings and critical errors of an execution
+type hostReport struct {
+	warnings  []string
+	criticals []string
+}
+
+func (r *hostReport) add(status Status, message string) {
+	switch status {
+	case StatusWarning:
+		r.warnings = append(r.warnings, message)
+	case StatusCritical:
+		r.criticals = append(r.criticals, message)
+	}
+}
+
+// Execute executes an healthcheck on the host
+func (h *HostHealthcheck) Execute(ctx context.Context) error {
+	_, err := h.ExecuteWithDetails(ctx)
+	return err
+}
+
+// ExecuteWithDetails executes the healthcheck, returning the measured values
+// and the warnings as details
+func (h *HostHealthcheck) ExecuteWithDetails(ctx context.Context) (map[string]interface{}, error) {
+	h.LogDebug("start executing healthcheck")
+	details := map[string]interface{}{}
+	report := &hostReport{}
+	if h.Config.DiskUsage.isSet() || h.Config.InodeUsage.isSet() {
+		filesystems := map[string]interface{}{}
+		for _, mount := range h.mounts {
+			diskUsage, inodeUsage, err := filesystemUsage(mount)
+			if err != nil {
+				return details, errors.Wrapf(err, "Fail to read the filesystem statistics for %s", mount)
+			}
+			filesystems[mount] = map[string]interface{}{
+				"disk-usage":  diskUsage,
+				"inode-usage": inodeUsage,
+			}
+			report.add(h.Config.DiskUsage.above(diskUsage), fmt.Sprintf("disk usage on %s is %.2f%%", mount, diskUsage))
+			report.add(h.Config.InodeUsage.above(inodeUsage), fmt.Sprintf("inode usage on %s is %.2f%%", mount, inodeUsage))
+		}
+		details["filesystems"] = filesystems
+	}
+	if h.Config.MemoryAvailable.isSet() || h.Config.SwapAvailable.isSet() {
+		memory, swap, err := memoryAvailable(h.fs)
+		if err != nil {
+			return details, errors.Wrap(err, "Fail to read the memory statistics")
+		}
+		details["memory-available"] = memory
+		details["swap-available"] = swap
+		report.add(h.Config.MemoryAvailable.below(memory), fmt.Sprintf("available memory is %.2f%%", memory))
+		report.add(h.Config.SwapAvailable.below(swap), fmt.Sprintf("available swap is %.2f%%", swap))
+	}
+	if h.Config.LoadPerCPU.isSet() {
+		load, err := loadPerCPU(h.fs)
+		if err != nil {
+			return details, errors.Wrap(err, "Fail to read the load average")
+		}
+		details["load-per-cpu"] = load
+		report.add(h.Config.LoadPerCPU.above(load), fmt.Sprintf("load per CPU is %.2f", load))
+	}
+	if len(report.warnings) != 0 {
+		details["warnings"] = report.warnings
+	}
+	if len(report.criticals) != 0 {
+		return details, errors.New(strings.Join(report.criticals, ", "))
+	}
+	if len(report.warnings) != 0 {
+		return details, NewStatusError(StatusWarning, errors.New(strings.Join(report.warnings, ", ")))
+	}
+	return details, nil
+}
+
+// NewHostHealthcheck creates a host healthcheck from a logger and a configuration
+func NewHostHealthcheck(logger *zap.Logger, config *HostHealthcheckConfiguration) *HostHealthcheck {
+	return &HostHealthcheck{
+		Logger: logger,
+		Config: config,
+	}
+}
+
+// MarshalJSON marshal to json a host healthcheck
+func (h *HostHealthcheck) MarshalJSON() ([]byte, error) {
+	return json.Marshal(h.Config)
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HostHealthcheckConfiguration) DeepCopyInto(out *HostHealthcheckConfiguration) {
+	*out = *in
+	in.Base.DeepCopyInto(&out.Base)
+	if in.Mounts != nil {
+		in, out := &in.Mounts, &out.Mounts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HostHealthcheckConfiguration.
+func (in *HostHealthcheckConfiguration) DeepCopy() *HostHealthcheckConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(HostHealthcheckConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
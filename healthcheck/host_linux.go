@@ -0,0 +1,58 @@
+//go:build linux
+
+package healthcheck
+
+import (
+	"runtime"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/procfs"
+	"golang.org/x/sys/unix"
+)
+
+// filesystemUsage returns the disk and inode usage percentages of a mount point
+func filesystemUsage(mount string) (float64, float64, error) {
+	var stat unix.Statfs_t
+	err := unix.Statfs(mount, &stat)
+	if err != nil {
+		return 0, 0, err
+	}
+	diskUsage := float64(0)
+	// same computation as df, blocks reserved to root are not available
+	used := stat.Blocks - stat.Bfree
+	if used+stat.Bavail != 0 {
+		diskUsage = float64(used) / float64(used+stat.Bavail) * 100
+	}
+	inodeUsage := float64(0)
+	if stat.Files != 0 {
+		inodeUsage = float64(stat.Files-stat.Ffree) / float64(stat.Files) * 100
+	}
+	return diskUsage, inodeUsage, nil
+}
+
+// memoryAvailable returns the available memory and swap percentages
+func memoryAvailable(fs procfs.FS) (float64, float64, error) {
+	meminfo, err := fs.Meminfo()
+	if err != nil {
+		return 0, 0, err
+	}
+	if meminfo.MemTotal == nil || meminfo.MemAvailable == nil || *meminfo.MemTotal == 0 {
+		return 0, 0, errors.New("The available memory is not reported by the kernel")
+	}
+	memory := float64(*meminfo.MemAvailable) / float64(*meminfo.MemTotal) * 100
+	// hosts without swap are considered as having all of it available
+	swap := float64(100)
+	if meminfo.SwapTotal != nil && meminfo.SwapFree != nil && *meminfo.SwapTotal != 0 {
+		swap = float64(*meminfo.SwapFree) / float64(*meminfo.SwapTotal) * 100
+	}
+	return memory, swap, nil
+}
+
+// loadPerCPU returns the 1 minute load average divided by the number of CPUs
+func loadPerCPU(fs procfs.FS) (float64, error) {
+	load, err := fs.LoadAvg()
+	if err != nil {
+		return 0, err
+	}
+	return load.Load1 / float64(runtime.NumCPU()), nil
+}
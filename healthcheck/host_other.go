@@ -0,0 +1,25 @@
+//go:build !linux
+
+package healthcheck
+
+import (
+	"github.com/pkg/errors"
+	"github.com/prometheus/procfs"
+)
+
+var errHostUnsupported = NewStatusError(StatusUnknown, errors.New("Host healthchecks are only supported on Linux"))
+
+// filesystemUsage returns the disk and inode usage percentages of a mount point
+func filesystemUsage(mount string) (float64, float64, error) {
+	return 0, 0, errHostUnsupported
+}
+
+// memoryAvailable returns the available memory and swap percentages
+func memoryAvailable(fs procfs.FS) (float64, float64, error) {
+	return 0, 0, errHostUnsupported
+}
+
+// loadPerCPU returns the 1 minute load average divided by the number of CPUs
+func loadPerCPU(fs procfs.FS) (float64, error) {
+	return 0, errHostUnsupported
+}
@@ -0,0 +1,118 @@
+package healthcheck
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/appclacks/cabourotte/prometheus"
+)
+
+func TestHostExecuteSuccess(t *testing.T) {
+	h := NewHostHealthcheck(
+		zap.NewExample(),
+		&HostHealthcheckConfiguration{
+			DiskUsage:       Threshold{Warning: 99.99, Critical: 100},
+			InodeUsage:      Threshold{Critical: 100},
+			MemoryAvailable: Threshold{Critical: 0.001},
+			LoadPerCPU:      Threshold{Critical: 1000},
+			Timeout:         Duration(time.Second * 2),
+		})
+	err := h.Initialize()
+	if err != nil {
+		t.Fatalf("Fail to initialize the healthcheck :\n%v", err)
+	}
+	details, err := h.ExecuteWithDetails(context.Background())
+	if err != nil {
+		t.Fatalf("healthcheck error :\n%v", err)
+	}
+	filesystems, ok := details["filesystems"].(map[string]interface{})
+	if !ok || filesystems["/"] == nil {
+		t.Fatalf("Invalid details: %v", details)
+	}
+	if _, ok := details["load-per-cpu"]; !ok {
+		t.Fatalf("Invalid details: %v", details)
+	}
+}
+
+func TestHostExecuteThresholds(t *testing.T) {
+	h := NewHostHealthcheck(
+		zap.NewExample(),
+		&HostHealthcheckConfiguration{
+			MemoryAvailable: Threshold{Warning: 100},
+			Timeout:         Duration(time.Second * 2),
+		})
+	err := h.Initialize()
+	if err != nil {
+		t.Fatalf("Fail to initialize the healthcheck :\n%v", err)
+	}
+	details, err := h.ExecuteWithDetails(context.Background())
+	if ErrorStatus(err) != StatusWarning {
+		t.Fatalf("healthcheck was expected to return a warning :\n%v", err)
+	}
+	if len(details["warnings"].([]string)) != 1 {
+		t.Fatalf("Invalid details: %v", details)
+	}
+	h.Config.MemoryAvailable = Threshold{Critical: 100}
+	err = h.Execute(context.Background())
+	if ErrorStatus(err) != StatusCritical {
+		t.Fatalf("healthcheck was expected to fail")
+	}
+}
+
+func TestHostValidate(t *testing.T) {
+	cases := []HostHealthcheckConfiguration{
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Timeout: Duration(time.Second)},
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Timeout: Duration(time.Second), DiskUsage: Threshold{Warning: 110}},
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Timeout: Duration(time.Second), DiskUsage: Threshold{Warning: 90, Critical: 80}},
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Timeout: Duration(time.Second), MemoryAvailable: Threshold{Warning: 5, Critical: 10}},
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, DiskUsage: Threshold{Warning: 80}},
+	}
+	for i := range cases {
+		err := cases[i].Validate()
+		if err == nil {
+			t.Fatalf("Validation was expected to fail for case %d", i)
+		}
+	}
+}
+
+func TestHostAddCheckUnchanged(t *testing.T) {
+	prom, err := prometheus.New()
+	if err != nil {
+		t.Fatalf("Error creating prometheus component :\n%v", err)
+	}
+	component, err := New(zap.NewExample(), make(chan *Result, 10), prom, []string{})
+	if err != nil {
+		t.Fatalf("Fail to create the component\n%v", err)
+	}
+	newCheck := func() *HostHealthcheck {
+		return NewHostHealthcheck(
+			zap.NewExample(),
+			&HostHealthcheckConfiguration{
+				Base: Base{
+					Name:     "foo",
+					Interval: Duration(time.Second * 10),
+				},
+				DiskUsage: Threshold{Critical: 100},
+				Timeout:   Duration(time.Second * 2),
+			})
+	}
+	err = component.AddCheck(newCheck())
+	if err != nil {
+		t.Fatalf("Fail to add the healthcheck\n%v", err)
+	}
+	wrapper := component.Healthchecks["foo"]
+	err = component.AddCheck(newCheck())
+	if err != nil {
+		t.Fatalf("Fail to add the healthcheck\n%v", err)
+	}
+	if component.Healthchecks["foo"] != wrapper {
+		t.Fatalf("The healthcheck was replaced")
+	}
+	err = component.Stop()
+	if err != nil {
+		t.Fatalf("Fail to stop the component\n%v", err)
+	}
+}
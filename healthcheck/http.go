@@ -15,8 +15,6 @@ import (
 	"github.com/appclacks/cabourotte/tls"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
-
-	"gopkg.in/tomb.v2"
 )
 
 // HTTPHealthcheckConfiguration defines an HTTP healthcheck configuration
@@ -46,8 +44,8 @@ type HTTPHealthcheckConfiguration struct {
 
 // Validate validates the healthcheck configuration
 func (config *HTTPHealthcheckConfiguration) Validate() error {
-	if config.Base.Name == "" {
-		return errors.New("The healthcheck name is missing")
+	if err := config.Base.validate(); err != nil {
+		return err
 	}
 	if len(config.ValidStatus) == 0 {
 		return errors.New("At least one valid status code should be provided")
@@ -68,7 +66,10 @@ func (config *HTTPHealthcheckConfiguration) Validate() error {
 	} else {
 		config.Method = "GET"
 	}
-	if !config.Base.OneOff {
+	if err := config.Base.validateTimeout(config.Timeout); err != nil {
+		return err
+	}
+	if !config.Base.OneOff && config.Base.Schedule == "" {
 		if config.Base.Interval < Duration(2*time.Second) {
 			return errors.New("The healthcheck interval should be greater than 2 second")
 		}
@@ -90,22 +91,26 @@ type HTTPHealthcheck struct {
 	URL    string
 
 	Tick   *time.Ticker
-	t      tomb.Tomb
 	Client *http.Client
 }
 
 // buildURL build the target URL for the HTTP healthcheck, depending of its
 // configuration
 func (h *HTTPHealthcheck) buildURL() {
+	h.URL = buildHTTPURL(h.Config.Protocol, h.Config.Target, h.Config.Port, h.Config.Path)
+}
+
+// buildHTTPURL builds an URL from a protocol, a target, a port and a path
+func buildHTTPURL(p Protocol, target string, port uint, path string) string {
 	protocol := "http"
-	if h.Config.Protocol == HTTPS {
+	if p == HTTPS {
 		protocol = "https"
 	}
-	h.URL = fmt.Sprintf(
+	return fmt.Sprintf(
 		"%s://%s%s",
 		protocol,
-		net.JoinHostPort(h.Config.Target, fmt.Sprintf("%d", h.Config.Port)),
-		h.Config.Path)
+		net.JoinHostPort(target, fmt.Sprintf("%d", port)),
+		path)
 }
 
 // Summary returns an healthcheck summary
@@ -121,39 +126,55 @@ func (h *HTTPHealthcheck) Summary() string {
 	return summary
 }
 
-// Initialize the healthcheck.
-func (h *HTTPHealthcheck) Initialize() error {
-	h.buildURL()
-
-	dialer := net.Dialer{}
-	if h.Config.SourceIP != nil {
-		srcIP := net.IP(h.Config.SourceIP).String()
+// newDialer creates a dialer using the source IP if configured
+func newDialer(sourceIP IP) (*net.Dialer, error) {
+	dialer := &net.Dialer{}
+	if sourceIP != nil {
+		srcIP := net.IP(sourceIP).String()
 		addr, err := net.ResolveTCPAddr("tcp", fmt.Sprintf("%s:0", srcIP))
 		if err != nil {
-			return errors.Wrapf(err, "Fail to set the source IP %s", srcIP)
-		}
-		dialer = net.Dialer{
-			LocalAddr: addr,
+			return nil, errors.Wrapf(err, "Fail to set the source IP %s", srcIP)
 		}
+		dialer.LocalAddr = addr
+	}
+	return dialer, nil
+}
+
+// newHTTPClient creates an HTTP client from the healthchecks client options
+func newHTTPClient(sourceIP IP, key string, cert string, cacert string, serverName string, insecure bool, followRedirect bool) (*http.Client, error) {
+	dialer, err := newDialer(sourceIP)
+	if err != nil {
+		return nil, err
 	}
-	tlsConfig, err := tls.GetTLSConfig(h.Config.Key, h.Config.Cert, h.Config.Cacert, h.Config.ServerName, h.Config.Insecure)
+	tlsConfig, err := tls.GetTLSConfig(key, cert, cacert, serverName, insecure)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	transport := &http.Transport{
 		DialContext:     dialer.DialContext,
 		TLSClientConfig: tlsConfig,
 	}
 	redirect := http.ErrUseLastResponse
-	if h.Config.Redirect {
+	if followRedirect {
 		redirect = nil
 	}
-	h.Client = &http.Client{
+	return &http.Client{
 		Transport: transport,
 		CheckRedirect: func(req *http.Request, via []*http.Request) error {
 			return redirect
 		},
+	}, nil
+}
+
+// Initialize the healthcheck.
+func (h *HTTPHealthcheck) Initialize() error {
+	h.buildURL()
+
+	client, err := newHTTPClient(h.Config.SourceIP, h.Config.Key, h.Config.Cert, h.Config.Cacert, h.Config.ServerName, h.Config.Insecure, h.Config.Redirect)
+	if err != nil {
+		return err
 	}
+	h.Client = client
 	return nil
 }
 
@@ -172,6 +193,11 @@ func (h *HTTPHealthcheck) SetSource(source string) {
 	h.Config.Base.Source = source
 }
 
+// Timeout returns the healthcheck timeout
+func (h *HTTPHealthcheck) Timeout() time.Duration {
+	return time.Duration(h.Config.Timeout)
+}
+
 // isSuccessful verifies if a healthcheck result is considered valid
 // depending of the healthcheck configuration
 func (h *HTTPHealthcheck) isSuccessful(response *http.Response) bool {
@@ -209,9 +235,8 @@ func (h *HTTPHealthcheck) LogInfo(message string) {
 }
 
 // Execute executes an healthcheck on the given target
-func (h *HTTPHealthcheck) Execute() error {
+func (h *HTTPHealthcheck) Execute(ctx context.Context) error {
 	h.LogDebug("start executing healthcheck")
-	ctx := h.t.Context(context.TODO())
 	body := bytes.NewBuffer([]byte(h.Config.Body))
 	req, err := http.NewRequest(h.Config.Method, h.URL, body)
 	if err != nil {
@@ -228,9 +253,7 @@ func (h *HTTPHealthcheck) Execute() error {
 		req.Host = h.Config.Host
 	}
 	client := h.Client
-	timeoutCtx, cancel := context.WithTimeout(ctx, time.Duration(h.Config.Timeout))
-	defer cancel()
-	req = req.WithContext(timeoutCtx)
+	req = req.WithContext(ctx)
 	if len(h.Config.Query) != 0 {
 		q := req.URL.Query()
 		for k, v := range h.Config.Query {
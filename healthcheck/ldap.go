@@ -0,0 +1,336 @@
+package healthcheck
+
+import (
+	"context"
+	cryptotls "crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"regexp"
+	"time"
+
+	"github.com/appclacks/cabourotte/tls"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// LDAPHealthcheckConfiguration defines a LDAP healthcheck configuration
+type LDAPHealthcheckConfiguration struct {
+	Base `json:",inline" yaml:",inline"`
+	// can be an IP or a domain
+	Target       string `json:"target"`
+	Port         uint   `json:"port"`
+	BindDN       string `json:"bind-dn,omitempty" yaml:"bind-dn,omitempty"`
+	BindPassword string `json:"bind-password,omitempty" yaml:"bind-password,omitempty"`
+	// the search is executed only if the base DN is set
+	BaseDN          string   `json:"base-dn,omitempty" yaml:"base-dn,omitempty"`
+	Filter          string   `json:"filter,omitempty"`
+	MinResults      uint     `json:"min-results,omitempty" yaml:"min-results,omitempty"`
+	Attribute       string   `json:"attribute,omitempty"`
+	AttributeRegexp []Regexp `json:"attribute-regexp,omitempty" yaml:"attribute-regexp,omitempty"`
+	SourceIP        IP       `json:"source-ip,omitempty" yaml:"source-ip,omitempty"`
+	// LDAPS
+	TLS        bool     `json:"tls"`
+	StartTLS   bool     `json:"start-tls" yaml:"start-tls"`
+	Insecure   bool     `json:"insecure"`
+	ServerName string   `json:"server-name"`
+	Timeout    Duration `json:"timeout"`
+	Key        string   `json:"key,omitempty"`
+	Cert       string   `json:"cert,omitempty"`
+	Cacert     string   `json:"cacert,omitempty"`
+}
+
+// Validate validates the healthcheck configuration
+func (config *LDAPHealthcheckConfiguration) Validate() error {
+	if err := config.Base.validate(); err != nil {
+		return err
+	}
+	if config.Target == "" {
+		return errors.New("The healthcheck target is missing")
+	}
+	if config.Port == 0 {
+		return errors.New("The healthcheck port is missing")
+	}
+	if config.Timeout == 0 {
+		return errors.New("The healthcheck timeout is missing")
+	}
+	if config.TLS && config.StartTLS {
+		return errors.New("The healthcheck tls and start-tls options are mutually exclusive")
+	}
+	if config.BindPassword != "" && config.BindDN == "" {
+		return errors.New("The healthcheck bind DN is missing")
+	}
+	if config.BaseDN == "" && (config.Filter != "" || config.MinResults != 0 || config.Attribute != "") {
+		return errors.New("The healthcheck base DN is missing")
+	}
+	if config.Filter != "" {
+		_, err := ldap.CompileFilter(config.Filter)
+		if err != nil {
+			return errors.Wrapf(err, "Invalid healthcheck filter %s", config.Filter)
+		}
+	}
+	if len(config.AttributeRegexp) != 0 && config.Attribute == "" {
+		return errors.New("The healthcheck attribute is missing")
+	}
+	if err := config.Base.validateTimeout(config.Timeout); err != nil {
+		return err
+	}
+	if !config.Base.OneOff && config.Base.Schedule == "" {
+		if config.Base.Interval < Duration(2*time.Second) {
+			return errors.New("The healthcheck interval should be greater than 2 second")
+		}
+		if config.Base.Interval < config.Timeout {
+			return errors.New("The healthcheck interval should be greater than the timeout")
+		}
+	}
+	if !((config.Key != "" && config.Cert != "") ||
+		(config.Key == "" && config.Cert == "")) {
+		return errors.New("Invalid certificates")
+	}
+	return nil
+}
+
+// LDAPHealthcheck defines a LDAP healthcheck
+type LDAPHealthcheck struct {
+	Logger *zap.Logger
+	Config *LDAPHealthcheckConfiguration
+	URL    string
+
+	dialer    *net.Dialer
+	tlsConfig *cryptotls.Config
+}
+
+// Summary returns an healthcheck summary
+func (h *LDAPHealthcheck) Summary() string {
+	summary := ""
+	if h.Config.Base.Description != "" {
+		summary = fmt.Sprintf("LDAP healthcheck %s on %s:%d", h.Config.Base.Description, h.Config.Target, h.Config.Port)
+
+	} else {
+		summary = fmt.Sprintf("LDAP healthcheck on %s:%d", h.Config.Target, h.Config.Port)
+	}
+
+	return summary
+}
+
+// Initialize the healthcheck.
+func (h *LDAPHealthcheck) Initialize() error {
+	scheme := "ldap"
+	if h.Config.TLS {
+		scheme = "ldaps"
+	}
+	h.URL = fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(h.Config.Target, fmt.Sprintf("%d", h.Config.Port)))
+	dialer, err := newDialer(h.Config.SourceIP)
+	if err != nil {
+		return err
+	}
+	dialer.Timeout = time.Duration(h.Config.Timeout)
+	h.dialer = dialer
+	if h.Config.TLS || h.Config.StartTLS {
+		tlsConfig, err := tls.GetTLSConfig(h.Config.Key, h.Config.Cert, h.Config.Cacert, h.Config.ServerName, h.Config.Insecure)
+		if err != nil {
+			return err
+		}
+		if tlsConfig.ServerName == "" {
+			tlsConfig.ServerName = h.Config.Target
+		}
+		h.tlsConfig = tlsConfig
+	}
+	return nil
+}
+
+// GetConfig get the config
+func (h *LDAPHealthcheck) GetConfig() interface{} {
+	return h.Config
+}
+
+// Base get the base configuration
+func (h *LDAPHealthcheck) Base() Base {
+	return h.Config.Base
+}
+
+// SetSource set the healthcheck source
+func (h *LDAPHealthcheck) SetSource(source string) {
+	h.Config.Base.Source = source
+}
+
+// Timeout returns the healthcheck timeout
+func (h *LDAPHealthcheck) Timeout() time.Duration {
+	return time.Duration(h.Config.Timeout)
+}
+
+// LogError logs an error with context
+func (h *LDAPHealthcheck) LogError(err error, message string) {
+	h.Logger.Error(err.Error(),
+		zap.String("extra", message),
+		zap.String("target", h.Config.Target),
+		zap.Uint("port", h.Config.Port),
+		zap.String("name", h.Config.Base.Name))
+}
+
+// LogDebug logs a message with context
+func (h *LDAPHealthcheck) LogDebug(message string) {
+	h.Logger.Debug(message,
+		zap.String("target", h.Config.Target),
+		zap.Uint("port", h.Config.Port),
+		zap.String("name", h.Config.Base.Name))
+}
+
+// LogInfo logs a message with context
+func (h *LDAPHealthcheck) LogInfo(message string) {
+	h.Logger.Info(message,
+		zap.String("target", h.Config.Target),
+		zap.Uint("port", h.Config.Port),
+		zap.String("name", h.Config.Base.Name))
+}
+
+// matchAttribute returns true if a value of the configured attribute matches
+// all the regexps for at least one entry
+func (h *LDAPHealthcheck) matchAttribute(entries []*ldap.Entry) bool {
+	for _, entry := range entries {
+		for _, value := range entry.GetAttributeValues(h.Config.Attribute) {
+			matches := true
+			for _, regex := range h.Config.AttributeRegexp {
+				r := regexp.Regexp(regex)
+				if !r.MatchString(value) {
+					matches = false
+					break
+				}
+			}
+			if matches {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// search executes the configured search
+func (h *LDAPHealthcheck) search(conn *ldap.Conn, details map[string]interface{}) error {
+	filter := h.Config.Filter
+	if filter == "" {
+		filter = "(objectClass=*)"
+	}
+	attributes := []string{"dn"}
+	if h.Config.Attribute != "" {
+		attributes = []string{h.Config.Attribute}
+	}
+	request := ldap.NewSearchRequest(
+		h.Config.BaseDN,
+		ldap.ScopeWholeSubtree,
+		ldap.NeverDerefAliases,
+		0,
+		int(time.Duration(h.Config.Timeout).Seconds()),
+		false,
+		filter,
+		attributes,
+		nil)
+	result, err := conn.Search(request)
+	if err != nil {
+		return errors.Wrapf(err, "LDAP search failed on %s", h.Config.BaseDN)
+	}
+	details["results"] = len(result.Entries)
+	minResults := h.Config.MinResults
+	if minResults == 0 {
+		minResults = 1
+	}
+	if uint(len(result.Entries)) < minResults {
+		return fmt.Errorf("The LDAP search returned %d entries, expected at least %d", len(result.Entries), minResults)
+	}
+	if h.Config.Attribute != "" && !h.matchAttribute(result.Entries) {
+		return fmt.Errorf("No value of the attribute %s matches the healthcheck regexps", h.Config.Attribute)
+	}
+	return nil
+}
+
+// Execute executes an healthcheck on the given target
+func (h *LDAPHealthcheck) Execute(ctx context.Context) error {
+	_, err := h.ExecuteWithDetails(ctx)
+	return err
+}
+
+// ExecuteWithDetails executes the healthcheck, returning the number of
+// search results as details
+func (h *LDAPHealthcheck) ExecuteWithDetails(ctx context.Context) (map[string]interface{}, error) {
+	h.LogDebug("start executing healthcheck")
+	details := map[string]interface{}{}
+	options := []ldap.DialOpt{ldap.DialWithDialer(h.dialer)}
+	if h.Config.TLS {
+		options = append(options, ldap.DialWithTLSConfig(h.tlsConfig))
+	}
+	conn, err := ldap.DialURL(h.URL, options...)
+	if err != nil {
+		return details, errors.Wrapf(err, "LDAP connection failed on %s", h.URL)
+	}
+	defer conn.Close()
+	// the LDAP client does not support contexts, the connection is closed
+	// to interrupt the healthcheck
+	stop := context.AfterFunc(ctx, func() {
+		conn.Close()
+	})
+	defer stop()
+	conn.SetTimeout(time.Duration(h.Config.Timeout))
+	if h.Config.StartTLS {
+		err = conn.StartTLS(h.tlsConfig)
+		if err != nil {
+			return details, errors.Wrap(err, "LDAP StartTLS failed")
+		}
+	}
+	if h.Config.BindDN != "" {
+		err = conn.Bind(h.Config.BindDN, h.Config.BindPassword)
+		if err != nil {
+			return details, errors.Wrapf(err, "LDAP bind failed for %s", h.Config.BindDN)
+		}
+	}
+	if h.Config.BaseDN != "" {
+		err = h.search(conn, details)
+		if err != nil {
+			return details, err
+		}
+	}
+	return details, nil
+}
+
+// NewLDAPHealthcheck creates a LDAP healthcheck from a logger and a configuration
+func NewLDAPHealthcheck(logger *zap.Logger, config *LDAPHealthcheckConfiguration) *LDAPHealthcheck {
+	return &LDAPHealthcheck{
+		Logger: logger,
+		Config: config,
+	}
+}
+
+// MarshalJSON marshal to json a LDAP healthcheck, the bind password is not exposed
+func (h *LDAPHealthcheck) MarshalJSON() ([]byte, error) {
+	config := h.Config.DeepCopy()
+	config.BindPassword = ""
+	return json.Marshal(config)
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LDAPHealthcheckConfiguration) DeepCopyInto(out *LDAPHealthcheckConfiguration) {
+	*out = *in
+	in.Base.DeepCopyInto(&out.Base)
+	if in.AttributeRegexp != nil {
+		in, out := &in.AttributeRegexp, &out.AttributeRegexp
+		*out = make([]Regexp, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SourceIP != nil {
+		in, out := &in.SourceIP, &out.SourceIP
+		*out = make(IP, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LDAPHealthcheckConfiguration.
+func (in *LDAPHealthcheckConfiguration) DeepCopy() *LDAPHealthcheckConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(LDAPHealthcheckConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
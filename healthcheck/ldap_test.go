@@ -0,0 +1,173 @@
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"go.uber.org/zap"
+)
+
+// ldapTestResult builds a LDAP result message
+func ldapTestResult(messageID int64, application ber.Tag, code int64) *ber.Packet {
+	packet := ber.NewSequence("LDAP Response")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageID, "MessageID"))
+	result := ber.Encode(ber.ClassApplication, ber.TypeConstructed, application, nil, "Result")
+	result.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, code, "resultCode"))
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "matchedDN"))
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "diagnosticMessage"))
+	packet.AppendChild(result)
+	return packet
+}
+
+// ldapTestEntry builds a LDAP search result entry message
+func ldapTestEntry(messageID int64, dn string, mail string) *ber.Packet {
+	packet := ber.NewSequence("LDAP Response")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageID, "MessageID"))
+	entry := ber.Encode(ber.ClassApplication, ber.TypeConstructed, 4, nil, "Search Result Entry")
+	entry.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, dn, "objectName"))
+	attributes := ber.NewSequence("attributes")
+	attribute := ber.NewSequence("attribute")
+	attribute.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "mail", "type"))
+	values := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "values")
+	values.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, mail, "value"))
+	attribute.AppendChild(values)
+	attributes.AppendChild(attribute)
+	entry.AppendChild(attributes)
+	packet.AppendChild(entry)
+	return packet
+}
+
+// ldapTestServer starts a minimal LDAP server accepting the "secret"
+// password and returning two entries for all searches
+func ldapTestServer(t *testing.T) (net.Listener, uint) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Fail to start the LDAP server :\n%v", err)
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				for {
+					packet, err := ber.ReadPacket(conn)
+					if err != nil || len(packet.Children) < 2 {
+						return
+					}
+					messageID := packet.Children[0].Value.(int64)
+					request := packet.Children[1]
+					responses := []*ber.Packet{}
+					switch request.Tag {
+					case 0:
+						code := int64(0)
+						if request.Children[2].Data.String() != "secret" {
+							code = 49
+						}
+						responses = append(responses, ldapTestResult(messageID, 1, code))
+					case 3:
+						responses = append(responses,
+							ldapTestEntry(messageID, "uid=alice,dc=example,dc=com", "alice@example.com"),
+							ldapTestEntry(messageID, "uid=bob,dc=example,dc=com", "bob@example.com"),
+							ldapTestResult(messageID, 5, 0))
+					default:
+						return
+					}
+					for _, response := range responses {
+						_, err := conn.Write(response.Bytes())
+						if err != nil {
+							return
+						}
+					}
+				}
+			}(conn)
+		}
+	}()
+	return listener, uint(listener.Addr().(*net.TCPAddr).Port)
+}
+
+func TestLDAPExecute(t *testing.T) {
+	listener, port := ldapTestServer(t)
+	defer listener.Close()
+	r := regexp.MustCompile("^bob@")
+	h := NewLDAPHealthcheck(
+		zap.NewExample(),
+		&LDAPHealthcheckConfiguration{
+			Target:          "127.0.0.1",
+			Port:            port,
+			BindDN:          "cn=cabourotte,dc=example,dc=com",
+			BindPassword:    "secret",
+			BaseDN:          "dc=example,dc=com",
+			Filter:          "(mail=*)",
+			MinResults:      2,
+			Attribute:       "mail",
+			AttributeRegexp: []Regexp{Regexp(*r)},
+			Timeout:         Duration(time.Second * 2),
+		})
+	err := h.Initialize()
+	if err != nil {
+		t.Fatalf("Initialization error :\n%v", err)
+	}
+	details, err := h.ExecuteWithDetails(context.Background())
+	if err != nil {
+		t.Fatalf("healthcheck error :\n%v", err)
+	}
+	if details["results"] != 2 {
+		t.Fatalf("Invalid details: %v", details)
+	}
+	h.Config.MinResults = 3
+	err = h.Execute(context.Background())
+	if err == nil {
+		t.Fatalf("healthcheck was expected to fail")
+	}
+	h.Config.MinResults = 0
+	r = regexp.MustCompile("^carol@")
+	h.Config.AttributeRegexp = []Regexp{Regexp(*r)}
+	err = h.Execute(context.Background())
+	if err == nil {
+		t.Fatalf("healthcheck was expected to fail")
+	}
+	h.Config.BindPassword = "wrong"
+	h.Config.BaseDN = ""
+	err = h.Execute(context.Background())
+	if err == nil {
+		t.Fatalf("healthcheck was expected to fail")
+	}
+}
+
+func TestLDAPValidate(t *testing.T) {
+	cases := []LDAPHealthcheckConfiguration{
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Target: "127.0.0.1", Port: 389, Timeout: Duration(time.Second), TLS: true, StartTLS: true},
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Target: "127.0.0.1", Port: 389, Timeout: Duration(time.Second), Filter: "(uid=*)"},
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Target: "127.0.0.1", Port: 389, Timeout: Duration(time.Second), BaseDN: "dc=example", Filter: "uid="},
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Target: "127.0.0.1", Port: 389, Timeout: Duration(time.Second), BindPassword: "secret"},
+	}
+	for i := range cases {
+		err := cases[i].Validate()
+		if err == nil {
+			t.Fatalf("Validation was expected to fail for case %d", i)
+		}
+	}
+}
+
+func TestLDAPMarshalJSONRedacted(t *testing.T) {
+	h := NewLDAPHealthcheck(zap.NewExample(), &LDAPHealthcheckConfiguration{BindPassword: "s3cr3t"})
+	out, err := json.Marshal(h)
+	if err != nil {
+		t.Fatalf("Fail to marshal the healthcheck :\n%v", err)
+	}
+	if strings.Contains(string(out), "s3cr3t") {
+		t.Fatalf("The credentials were exposed: %s", out)
+	}
+	if h.Config.BindPassword != "s3cr3t" {
+		t.Fatalf("The healthcheck configuration was modified")
+	}
+}
@@ -0,0 +1,418 @@
+package healthcheck
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	cryptotls "crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/appclacks/cabourotte/tls"
+	"github.com/eclipse/paho.golang/packets"
+	paho "github.com/eclipse/paho.golang/paho"
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	// MQTTVersion311 the MQTT 3.1.1 protocol version
+	MQTTVersion311 = "3.1.1"
+	// MQTTVersion5 the MQTT 5 protocol version
+	MQTTVersion5 = "5"
+)
+
+// MQTTHealthcheckConfiguration defines a MQTT healthcheck configuration
+type MQTTHealthcheckConfiguration struct {
+	Base `json:",inline" yaml:",inline"`
+	// can be an IP or a domain
+	Target   string `json:"target"`
+	Port     uint   `json:"port"`
+	Version  string `json:"version,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	ClientID string `json:"client-id,omitempty" yaml:"client-id,omitempty"`
+	// the probe messages are published to a unique topic under this prefix
+	Topic      string   `json:"topic,omitempty"`
+	QoS        byte     `json:"qos"`
+	SourceIP   IP       `json:"source-ip,omitempty" yaml:"source-ip,omitempty"`
+	TLS        bool     `json:"tls"`
+	Insecure   bool     `json:"insecure"`
+	ServerName string   `json:"server-name"`
+	Timeout    Duration `json:"timeout"`
+	Key        string   `json:"key,omitempty"`
+	Cert       string   `json:"cert,omitempty"`
+	Cacert     string   `json:"cacert,omitempty"`
+}
+
+// Validate validates the healthcheck configuration
+func (config *MQTTHealthcheckConfiguration) Validate() error {
+	if err := config.Base.validate(); err != nil {
+		return err
+	}
+	if config.Target == "" {
+		return errors.New("The healthcheck target is missing")
+	}
+	if config.Port == 0 {
+		return errors.New("The healthcheck port is missing")
+	}
+	if config.Timeout == 0 {
+		return errors.New("The healthcheck timeout is missing")
+	}
+	if config.Version == "" {
+		config.Version = MQTTVersion311
+	}
+	if config.Version != MQTTVersion311 && config.Version != MQTTVersion5 {
+		return fmt.Errorf("Invalid healthcheck MQTT version %s", config.Version)
+	}
+	if config.QoS > 2 {
+		return errors.New("The healthcheck QoS should be 0, 1 or 2")
+	}
+	if config.Topic == "" {
+		config.Topic = "cabourotte/healthcheck"
+	}
+	if err := config.Base.validateTimeout(config.Timeout); err != nil {
+		return err
+	}
+	if !config.Base.OneOff && config.Base.Schedule == "" {
+		if config.Base.Interval < Duration(2*time.Second) {
+			return errors.New("The healthcheck interval should be greater than 2 second")
+		}
+		if config.Base.Interval < config.Timeout {
+			return errors.New("The healthcheck interval should be greater than the timeout")
+		}
+	}
+	if !((config.Key != "" && config.Cert != "") ||
+		(config.Key == "" && config.Cert == "")) {
+		return errors.New("Invalid certificates")
+	}
+	return nil
+}
+
+// MQTTHealthcheck defines a MQTT healthcheck
+type MQTTHealthcheck struct {
+	Logger *zap.Logger
+	Config *MQTTHealthcheckConfiguration
+	URL    string
+
+	dialer    *net.Dialer
+	tlsConfig *cryptotls.Config
+}
+
+// Summary returns an healthcheck summary
+func (h *MQTTHealthcheck) Summary() string {
+	summary := ""
+	if h.Config.Base.Description != "" {
+		summary = fmt.Sprintf("MQTT healthcheck %s on %s:%d", h.Config.Base.Description, h.Config.Target, h.Config.Port)
+
+	} else {
+		summary = fmt.Sprintf("MQTT healthcheck on %s:%d", h.Config.Target, h.Config.Port)
+	}
+
+	return summary
+}
+
+// Initialize the healthcheck.
+func (h *MQTTHealthcheck) Initialize() error {
+	h.URL = net.JoinHostPort(h.Config.Target, fmt.Sprintf("%d", h.Config.Port))
+	dialer, err := newDialer(h.Config.SourceIP)
+	if err != nil {
+		return err
+	}
+	h.dialer = dialer
+	if h.Config.TLS {
+		tlsConfig, err := tls.GetTLSConfig(h.Config.Key, h.Config.Cert, h.Config.Cacert, h.Config.ServerName, h.Config.Insecure)
+		if err != nil {
+			return err
+		}
+		if tlsConfig.ServerName == "" {
+			tlsConfig.ServerName = h.Config.Target
+		}
+		h.tlsConfig = tlsConfig
+	}
+	return nil
+}
+
+// GetConfig get the config
+func (h *MQTTHealthcheck) GetConfig() interface{} {
+	return h.Config
+}
+
+// Base get the base configuration
+func (h *MQTTHealthcheck) Base() Base {
+	return h.Config.Base
+}
+
+// SetSource set the healthcheck source
+func (h *MQTTHealthcheck) SetSource(source string) {
+	h.Config.Base.Source = source
+}
+
+// Timeout returns the healthcheck timeout
+func (h *MQTTHealthcheck) Timeout() time.Duration {
+	return time.Duration(h.Config.Timeout)
+}
+
+// LogError logs an error with context
+func (h *MQTTHealthcheck) LogError(err error, message string) {
+	h.Logger.Error(err.Error(),
+		zap.String("extra", message),
+		zap.String("target", h.Config.Target),
+		zap.Uint("port", h.Config.Port),
+		zap.String("name", h.Config.Base.Name))
+}
+
+// LogDebug logs a message with context
+func (h *MQTTHealthcheck) LogDebug(message string) {
+	h.Logger.Debug(message,
+		zap.String("target", h.Config.Target),
+		zap.Uint("port", h.Config.Port),
+		zap.String("name", h.Config.Base.Name))
+}
+
+// LogInfo logs a message with context
+func (h *MQTTHealthcheck) LogInfo(message string) {
+	h.Logger.Info(message,
+		zap.String("target", h.Config.Target),
+		zap.Uint("port", h.Config.Port),
+		zap.String("name", h.Config.Base.Name))
+}
+
+// randomID returns a random hexadecimal identifier
+func randomID() (string, error) {
+	b := make([]byte, 8)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", errors.Wrap(err, "Fail to generate a random identifier")
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// waitToken waits for a MQTT 3.1.1 operation to complete or for the context
+// to be done
+func waitToken(ctx context.Context, token mqtt.Token) error {
+	select {
+	case <-token.Done():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// execute311 executes the healthcheck using the MQTT 3.1.1 protocol
+func (h *MQTTHealthcheck) execute311(ctx context.Context, clientID string, topic string, payload []byte, details map[string]interface{}) error {
+	scheme := "tcp"
+	if h.Config.TLS {
+		scheme = "ssl"
+	}
+	// the client needs a connection timeout even without deadline
+	connectTimeout := time.Duration(h.Config.Timeout)
+	if deadline, ok := ctx.Deadline(); ok {
+		connectTimeout = time.Until(deadline)
+	}
+	options := mqtt.NewClientOptions().
+		AddBroker(fmt.Sprintf("%s://%s", scheme, h.URL)).
+		SetClientID(clientID).
+		SetUsername(h.Config.Username).
+		SetPassword(h.Config.Password).
+		SetProtocolVersion(4).
+		SetCleanSession(true).
+		SetAutoReconnect(false).
+		SetConnectTimeout(connectTimeout).
+		SetDialer(h.dialer).
+		SetTLSConfig(h.tlsConfig)
+	client := mqtt.NewClient(options)
+	token := client.Connect()
+	if err := waitToken(ctx, token); err != nil {
+		// the client may still connect later
+		go func() {
+			<-token.Done()
+			client.Disconnect(0)
+		}()
+		return errors.Wrap(err, "Timeout waiting for the MQTT CONNACK")
+	}
+	details["connack-code"] = token.(*mqtt.ConnectToken).ReturnCode()
+	if token.Error() != nil {
+		return errors.Wrap(token.Error(), "MQTT connection failed")
+	}
+	defer client.Disconnect(0)
+	received := make(chan time.Time, 1)
+	token = client.Subscribe(topic, h.Config.QoS, func(_ mqtt.Client, message mqtt.Message) {
+		if bytes.Equal(message.Payload(), payload) {
+			select {
+			case received <- time.Now():
+			default:
+			}
+		}
+	})
+	if err := waitToken(ctx, token); err != nil {
+		return errors.Wrap(err, "Timeout waiting for the MQTT SUBACK")
+	}
+	if token.Error() != nil {
+		return errors.Wrapf(token.Error(), "Fail to subscribe to %s", topic)
+	}
+	start := time.Now()
+	token = client.Publish(topic, h.Config.QoS, false, payload)
+	if err := waitToken(ctx, token); err != nil {
+		return errors.Wrap(err, "Timeout publishing the MQTT message")
+	}
+	if token.Error() != nil {
+		return errors.Wrapf(token.Error(), "Fail to publish to %s", topic)
+	}
+	return h.waitMessage(ctx, start, received, details)
+}
+
+// execute5 executes the healthcheck using the MQTT 5 protocol
+func (h *MQTTHealthcheck) execute5(ctx context.Context, clientID string, topic string, payload []byte, details map[string]interface{}) error {
+	conn, err := h.dialer.DialContext(ctx, "tcp", h.URL)
+	if err != nil {
+		return errors.Wrapf(err, "MQTT connection failed on %s", h.URL)
+	}
+	if h.tlsConfig != nil {
+		tlsConn := cryptotls.Client(conn, h.tlsConfig)
+		err = tlsConn.HandshakeContext(ctx)
+		if err != nil {
+			conn.Close()
+			return errors.Wrapf(err, "TLS handshake failed on %s", h.URL)
+		}
+		conn = tlsConn
+	}
+	received := make(chan time.Time, 1)
+	client := paho.NewClient(paho.ClientConfig{
+		ClientID: clientID,
+		Conn:     packets.NewThreadSafeConn(conn),
+		OnPublishReceived: []func(paho.PublishReceived) (bool, error){
+			func(p paho.PublishReceived) (bool, error) {
+				if bytes.Equal(p.Packet.Payload, payload) {
+					select {
+					case received <- time.Now():
+					default:
+					}
+				}
+				return true, nil
+			},
+		},
+	})
+	connect := &paho.Connect{
+		ClientID:   clientID,
+		CleanStart: true,
+		KeepAlive:  30,
+	}
+	if h.Config.Username != "" {
+		connect.Username = h.Config.Username
+		connect.UsernameFlag = true
+	}
+	if h.Config.Password != "" {
+		connect.Password = []byte(h.Config.Password)
+		connect.PasswordFlag = true
+	}
+	connack, err := client.Connect(ctx, connect)
+	if connack != nil {
+		details["connack-code"] = connack.ReasonCode
+	}
+	if err != nil {
+		conn.Close()
+		return errors.Wrap(err, "MQTT connection failed")
+	}
+	defer func() {
+		_ = client.Disconnect(&paho.Disconnect{ReasonCode: 0})
+	}()
+	_, err = client.Subscribe(ctx, &paho.Subscribe{
+		Subscriptions: []paho.SubscribeOptions{
+			{Topic: topic, QoS: h.Config.QoS},
+		},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "Fail to subscribe to %s", topic)
+	}
+	start := time.Now()
+	_, err = client.Publish(ctx, &paho.Publish{
+		Topic:   topic,
+		QoS:     h.Config.QoS,
+		Payload: payload,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "Fail to publish to %s", topic)
+	}
+	return h.waitMessage(ctx, start, received, details)
+}
+
+// waitMessage waits for the probe message to be received
+func (h *MQTTHealthcheck) waitMessage(ctx context.Context, start time.Time, received chan time.Time, details map[string]interface{}) error {
+	select {
+	case t := <-received:
+		details["round-trip"] = t.Sub(start).String()
+		return nil
+	case <-ctx.Done():
+		return errors.New("The MQTT probe message was not received before the timeout")
+	}
+}
+
+// Execute executes an healthcheck on the given target
+func (h *MQTTHealthcheck) Execute(ctx context.Context) error {
+	_, err := h.ExecuteWithDetails(ctx)
+	return err
+}
+
+// ExecuteWithDetails executes the healthcheck, returning the CONNACK code
+// and the probe message round trip time as details
+func (h *MQTTHealthcheck) ExecuteWithDetails(ctx context.Context) (map[string]interface{}, error) {
+	h.LogDebug("start executing healthcheck")
+	id, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+	clientID := h.Config.ClientID
+	if clientID == "" {
+		clientID = fmt.Sprintf("cabourotte-%s", id)
+	}
+	topic := fmt.Sprintf("%s/%s", h.Config.Topic, id)
+	payload := []byte(fmt.Sprintf("cabourotte probe %s", id))
+	details := map[string]interface{}{}
+	if h.Config.Version == MQTTVersion5 {
+		err = h.execute5(ctx, clientID, topic, payload, details)
+	} else {
+		err = h.execute311(ctx, clientID, topic, payload, details)
+	}
+	return details, err
+}
+
+// NewMQTTHealthcheck creates a MQTT healthcheck from a logger and a configuration
+func NewMQTTHealthcheck(logger *zap.Logger, config *MQTTHealthcheckConfiguration) *MQTTHealthcheck {
+	return &MQTTHealthcheck{
+		Logger: logger,
+		Config: config,
+	}
+}
+
+// MarshalJSON marshal to json a MQTT healthcheck, the password is not exposed
+func (h *MQTTHealthcheck) MarshalJSON() ([]byte, error) {
+	config := h.Config.DeepCopy()
+	config.Password = ""
+	return json.Marshal(config)
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MQTTHealthcheckConfiguration) DeepCopyInto(out *MQTTHealthcheckConfiguration) {
+	*out = *in
+	in.Base.DeepCopyInto(&out.Base)
+	if in.SourceIP != nil {
+		in, out := &in.SourceIP, &out.SourceIP
+		*out = make(IP, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MQTTHealthcheckConfiguration.
+func (in *MQTTHealthcheckConfiguration) DeepCopy() *MQTTHealthcheckConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(MQTTHealthcheckConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
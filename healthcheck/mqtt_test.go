@@ -0,0 +1,166 @@
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	packets5 "github.com/eclipse/paho.golang/packets"
+	packets3 "github.com/eclipse/paho.mqtt.golang/packets"
+	"go.uber.org/zap"
+)
+
+// mqttTestBroker starts a minimal MQTT broker echoing the published
+// messages to the client. Clients using the "bad" username are rejected.
+func mqttTestBroker(t *testing.T, version string) (net.Listener, uint) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Fail to start the broker :\n%v", err)
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			if version == MQTTVersion5 {
+				go mqttTestSession5(conn)
+			} else {
+				go mqttTestSession3(conn)
+			}
+		}
+	}()
+	return listener, uint(listener.Addr().(*net.TCPAddr).Port)
+}
+
+func mqttTestSession3(conn net.Conn) {
+	defer conn.Close()
+	for {
+		packet, err := packets3.ReadPacket(conn)
+		if err != nil {
+			return
+		}
+		switch p := packet.(type) {
+		case *packets3.ConnectPacket:
+			connack := packets3.NewControlPacket(packets3.Connack).(*packets3.ConnackPacket)
+			if p.Username == "bad" {
+				connack.ReturnCode = packets3.ErrRefusedNotAuthorised
+			}
+			err = connack.Write(conn)
+		case *packets3.SubscribePacket:
+			suback := packets3.NewControlPacket(packets3.Suback).(*packets3.SubackPacket)
+			suback.MessageID = p.MessageID
+			suback.ReturnCodes = p.Qoss
+			err = suback.Write(conn)
+		case *packets3.PublishPacket:
+			err = p.Write(conn)
+		case *packets3.PingreqPacket:
+			err = packets3.NewControlPacket(packets3.Pingresp).Write(conn)
+		case *packets3.DisconnectPacket:
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func mqttTestSession5(conn net.Conn) {
+	defer conn.Close()
+	for {
+		packet, err := packets5.ReadPacket(conn)
+		if err != nil {
+			return
+		}
+		switch p := packet.Content.(type) {
+		case *packets5.Connect:
+			connack := packets5.NewControlPacket(packets5.CONNACK)
+			if p.Username == "bad" {
+				connack.Content.(*packets5.Connack).ReasonCode = packets5.ConnackNotAuthorized
+			}
+			_, err = connack.WriteTo(conn)
+		case *packets5.Subscribe:
+			suback := packets5.NewControlPacket(packets5.SUBACK)
+			suback.Content.(*packets5.Suback).PacketID = p.PacketID
+			suback.Content.(*packets5.Suback).Reasons = []byte{0}
+			_, err = suback.WriteTo(conn)
+		case *packets5.Publish:
+			publish := packets5.NewControlPacket(packets5.PUBLISH)
+			publish.Content.(*packets5.Publish).Topic = p.Topic
+			publish.Content.(*packets5.Publish).Payload = p.Payload
+			_, err = publish.WriteTo(conn)
+		case *packets5.Pingreq:
+			_, err = packets5.NewControlPacket(packets5.PINGRESP).WriteTo(conn)
+		case *packets5.Disconnect:
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func TestMQTTExecute(t *testing.T) {
+	for _, version := range []string{MQTTVersion311, MQTTVersion5} {
+		listener, port := mqttTestBroker(t, version)
+		h := NewMQTTHealthcheck(
+			zap.NewExample(),
+			&MQTTHealthcheckConfiguration{
+				Target:   "127.0.0.1",
+				Port:     port,
+				Version:  version,
+				Username: "cabourotte",
+				Password: "secret",
+				Topic:    "cabourotte/healthcheck",
+				Timeout:  Duration(time.Second * 2),
+			})
+		err := h.Initialize()
+		if err != nil {
+			t.Fatalf("Initialization error :\n%v", err)
+		}
+		details, err := h.ExecuteWithDetails(context.Background())
+		if err != nil {
+			t.Fatalf("healthcheck error for version %s :\n%v", version, err)
+		}
+		if _, ok := details["round-trip"]; !ok {
+			t.Fatalf("Invalid details: %v", details)
+		}
+		h.Config.Username = "bad"
+		err = h.Execute(context.Background())
+		if err == nil {
+			t.Fatalf("healthcheck was expected to fail for version %s", version)
+		}
+		listener.Close()
+	}
+}
+
+func TestMQTTValidate(t *testing.T) {
+	cases := []MQTTHealthcheckConfiguration{
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Target: "127.0.0.1", Port: 1883, Timeout: Duration(time.Second), Version: "4"},
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Target: "127.0.0.1", Port: 1883, Timeout: Duration(time.Second), QoS: 3},
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Target: "127.0.0.1", Timeout: Duration(time.Second)},
+	}
+	for i := range cases {
+		err := cases[i].Validate()
+		if err == nil {
+			t.Fatalf("Validation was expected to fail for case %d", i)
+		}
+	}
+}
+
+func TestMQTTMarshalJSONRedacted(t *testing.T) {
+	h := NewMQTTHealthcheck(zap.NewExample(), &MQTTHealthcheckConfiguration{Password: "s3cr3t"})
+	out, err := json.Marshal(h)
+	if err != nil {
+		t.Fatalf("Fail to marshal the healthcheck :\n%v", err)
+	}
+	if strings.Contains(string(out), "s3cr3t") {
+		t.Fatalf("The credentials were exposed: %s", out)
+	}
+	if h.Config.Password != "s3cr3t" {
+		t.Fatalf("The healthcheck configuration was modified")
+	}
+}
@@ -0,0 +1,254 @@
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"time"
+
+	"github.com/beevik/ntp"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	// ntpOffsetGauge the gauge exposing the clock offset of NTP healthchecks
+	ntpOffsetGauge = "healthcheck_ntp_offset_seconds"
+	// ntpDelayGauge the gauge exposing the round trip delay of NTP
+	// healthchecks
+	ntpDelayGauge = "healthcheck_ntp_delay_seconds"
+)
+
+// NTPHealthcheckConfiguration defines a NTP healthcheck configuration
+type NTPHealthcheckConfiguration struct {
+	Base `json:",inline" yaml:",inline"`
+	// can be an IP or a domain
+	Target     string   `json:"target"`
+	Port       uint     `json:"port"`
+	SourceIP   IP       `json:"source-ip,omitempty" yaml:"source-ip,omitempty"`
+	MaxOffset  Duration `json:"max-offset,omitempty" yaml:"max-offset,omitempty"`
+	MaxStratum uint     `json:"max-stratum,omitempty" yaml:"max-stratum,omitempty"`
+	Timeout    Duration `json:"timeout"`
+}
+
+// Validate validates the healthcheck configuration
+func (config *NTPHealthcheckConfiguration) Validate() error {
+	if err := config.Base.validate(); err != nil {
+		return err
+	}
+	if config.Target == "" {
+		return errors.New("The healthcheck target is missing")
+	}
+	if config.Port == 0 {
+		config.Port = 123
+	}
+	if config.Timeout == 0 {
+		return errors.New("The healthcheck timeout is missing")
+	}
+	if config.MaxStratum > 15 {
+		return errors.New("The healthcheck max stratum should be lower than 16")
+	}
+	if err := config.Base.validateTimeout(config.Timeout); err != nil {
+		return err
+	}
+	if !config.Base.OneOff && config.Base.Schedule == "" {
+		if config.Base.Interval < Duration(2*time.Second) {
+			return errors.New("The healthcheck interval should be greater than 2 second")
+		}
+		if config.Base.Interval < config.Timeout {
+			return errors.New("The healthcheck interval should be greater than the timeout")
+		}
+	}
+	return nil
+}
+
+// NTPHealthcheck defines a NTP healthcheck
+type NTPHealthcheck struct {
+	Logger *zap.Logger
+	Config *NTPHealthcheckConfiguration
+	URL    string
+}
+
+// Summary returns an healthcheck summary
+func (h *NTPHealthcheck) Summary() string {
+	summary := ""
+	if h.Config.Base.Description != "" {
+		summary = fmt.Sprintf("NTP healthcheck %s on %s:%d", h.Config.Base.Description, h.Config.Target, h.Config.Port)
+
+	} else {
+		summary = fmt.Sprintf("NTP healthcheck on %s:%d", h.Config.Target, h.Config.Port)
+	}
+
+	return summary
+}
+
+// Initialize the healthcheck.
+func (h *NTPHealthcheck) Initialize() error {
+	port := h.Config.Port
+	if port == 0 {
+		port = 123
+	}
+	h.URL = net.JoinHostPort(h.Config.Target, fmt.Sprintf("%d", port))
+	return nil
+}
+
+// GetConfig get the config
+func (h *NTPHealthcheck) GetConfig() interface{} {
+	return h.Config
+}
+
+// Base get the base configuration
+func (h *NTPHealthcheck) Base() Base {
+	return h.Config.Base
+}
+
+// SetSource set the healthcheck source
+func (h *NTPHealthcheck) SetSource(source string) {
+	h.Config.Base.Source = source
+}
+
+// Timeout returns the healthcheck timeout
+func (h *NTPHealthcheck) Timeout() time.Duration {
+	return time.Duration(h.Config.Timeout)
+}
+
+// LogError logs an error with context
+func (h *NTPHealthcheck) LogError(err error, message string) {
+	h.Logger.Error(err.Error(),
+		zap.String("extra", message),
+		zap.String("target", h.Config.Target),
+		zap.Uint("port", h.Config.Port),
+		zap.String("name", h.Config.Base.Name))
+}
+
+// LogDebug logs a message with context
+func (h *NTPHealthcheck) LogDebug(message string) {
+	h.Logger.Debug(message,
+		zap.String("target", h.Config.Target),
+		zap.Uint("port", h.Config.Port),
+		zap.String("name", h.Config.Base.Name))
+}
+
+// LogInfo logs a message with context
+func (h *NTPHealthcheck) LogInfo(message string) {
+	h.Logger.Info(message,
+		zap.String("target", h.Config.Target),
+		zap.Uint("port", h.Config.Port),
+		zap.String("name", h.Config.Base.Name))
+}
+
+// Execute executes an healthcheck on the given target
+func (h *NTPHealthcheck) Execute(ctx context.Context) error {
+	_, err := h.ExecuteWithDetails(ctx)
+	return err
+}
+
+// Gauges returns the clock offset and the round trip delay reported by a
+// result. They are NaN if the NTP server did not answer, so the previous
+// values are not exposed anymore.
+func (h *NTPHealthcheck) Gauges(result *Result) map[string]float64 {
+	gauges := map[string]float64{
+		ntpOffsetGauge: math.NaN(),
+		ntpDelayGauge:  math.NaN(),
+	}
+	if offset, ok := result.Details["offset"].(float64); ok {
+		gauges[ntpOffsetGauge] = offset
+	}
+	if delay, ok := result.Details["delay"].(float64); ok {
+		gauges[ntpDelayGauge] = delay
+	}
+	return gauges
+}
+
+// ExecuteWithDetails executes the healthcheck, returning the clock offset
+// and the round trip delay in seconds and the server stratum as details
+func (h *NTPHealthcheck) ExecuteWithDetails(ctx context.Context) (map[string]interface{}, error) {
+	h.LogDebug("start executing healthcheck")
+	stop := func() bool { return false }
+	defer func() { stop() }()
+	options := ntp.QueryOptions{
+		Timeout: time.Duration(h.Config.Timeout),
+		// the connection is closed when the context is done, so a
+		// cancelled healthcheck does not wait for the NTP server
+		Dialer: func(localAddress string, remoteAddress string) (net.Conn, error) {
+			dialer := net.Dialer{}
+			if localAddress != "" {
+				dialer.LocalAddr = &net.UDPAddr{IP: net.ParseIP(localAddress)}
+			}
+			conn, err := dialer.DialContext(ctx, "udp", remoteAddress)
+			if err != nil {
+				return nil, err
+			}
+			stop = context.AfterFunc(ctx, func() {
+				conn.Close()
+			})
+			return conn, nil
+		},
+	}
+	if h.Config.SourceIP != nil {
+		options.LocalAddress = net.IP(h.Config.SourceIP).String()
+	}
+	response, err := ntp.QueryWithOptions(h.URL, options)
+	if err != nil && ctx.Err() != nil {
+		return nil, errors.Wrapf(ctx.Err(), "NTP query failed on %s", h.URL)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "NTP query failed on %s", h.URL)
+	}
+	details := map[string]interface{}{
+		"offset":  response.ClockOffset.Seconds(),
+		"delay":   response.RTT.Seconds(),
+		"stratum": response.Stratum,
+	}
+	err = response.Validate()
+	if err != nil {
+		return details, errors.Wrapf(err, "Invalid NTP response from %s", h.URL)
+	}
+	offset := response.ClockOffset
+	if offset < 0 {
+		offset = -offset
+	}
+	if h.Config.MaxOffset != 0 && offset > time.Duration(h.Config.MaxOffset) {
+		return details, fmt.Errorf("The clock offset is %s, greater than %s", response.ClockOffset.String(), time.Duration(h.Config.MaxOffset).String())
+	}
+	if h.Config.MaxStratum != 0 && uint(response.Stratum) > h.Config.MaxStratum {
+		return details, fmt.Errorf("The server stratum is %d, greater than %d", response.Stratum, h.Config.MaxStratum)
+	}
+	return details, nil
+}
+
+// NewNTPHealthcheck creates a NTP healthcheck from a logger and a configuration
+func NewNTPHealthcheck(logger *zap.Logger, config *NTPHealthcheckConfiguration) *NTPHealthcheck {
+	return &NTPHealthcheck{
+		Logger: logger,
+		Config: config,
+	}
+}
+
+// MarshalJSON marshal to json a NTP healthcheck
+func (h *NTPHealthcheck) MarshalJSON() ([]byte, error) {
+	return json.Marshal(h.Config)
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NTPHealthcheckConfiguration) DeepCopyInto(out *NTPHealthcheckConfiguration) {
+	*out = *in
+	in.Base.DeepCopyInto(&out.Base)
+	if in.SourceIP != nil {
+		in, out := &in.SourceIP, &out.SourceIP
+		*out = make(IP, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NTPHealthcheckConfiguration.
+func (in *NTPHealthcheckConfiguration) DeepCopy() *NTPHealthcheckConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(NTPHealthcheckConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,157 @@
+package healthcheck
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// ntpTestTimestamp encodes a time as a NTP timestamp
+func ntpTestTimestamp(b []byte, t time.Time) {
+	seconds := uint64(t.Unix()) + 2208988800
+	fraction := uint64(t.Nanosecond()) << 32 / 1e9
+	binary.BigEndian.PutUint64(b, seconds<<32|fraction)
+}
+
+// ntpTestServer starts a NTP server whose clock is shifted by the offset
+func ntpTestServer(t *testing.T, offset time.Duration, stratum byte) (*net.UDPConn, uint) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	if err != nil {
+		t.Fatalf("Fail to start the NTP server :\n%v", err)
+	}
+	go func() {
+		request := make([]byte, 48)
+		for {
+			_, addr, err := conn.ReadFromUDP(request)
+			if err != nil {
+				return
+			}
+			now := time.Now().Add(offset)
+			response := make([]byte, 48)
+			// leap indicator 0, version 4, server mode
+			response[0] = 0x24
+			response[1] = stratum
+			response[2] = 6
+			response[3] = 0xec
+			copy(response[12:16], []byte{127, 0, 0, 1})
+			ntpTestTimestamp(response[16:24], now.Add(-time.Minute))
+			copy(response[24:32], request[40:48])
+			ntpTestTimestamp(response[32:40], now)
+			ntpTestTimestamp(response[40:48], now)
+			_, err = conn.WriteToUDP(response, addr)
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return conn, uint(conn.LocalAddr().(*net.UDPAddr).Port)
+}
+
+func TestNTPExecute(t *testing.T) {
+	conn, port := ntpTestServer(t, 0, 2)
+	defer conn.Close()
+	h := NewNTPHealthcheck(
+		zap.NewExample(),
+		&NTPHealthcheckConfiguration{
+			Target:     "127.0.0.1",
+			Port:       port,
+			MaxOffset:  Duration(time.Second),
+			MaxStratum: 3,
+			Timeout:    Duration(time.Second * 2),
+		})
+	err := h.Initialize()
+	if err != nil {
+		t.Fatalf("Initialization error :\n%v", err)
+	}
+	details, err := h.ExecuteWithDetails(context.Background())
+	if err != nil {
+		t.Fatalf("healthcheck error :\n%v", err)
+	}
+	if _, ok := details["offset"].(float64); !ok {
+		t.Fatalf("Invalid details: %v", details)
+	}
+	if _, ok := details["delay"].(float64); !ok {
+		t.Fatalf("Invalid details: %v", details)
+	}
+	h.Config.MaxStratum = 1
+	err = h.Execute(context.Background())
+	if err == nil {
+		t.Fatalf("healthcheck was expected to fail")
+	}
+}
+
+func TestNTPExecuteOffset(t *testing.T) {
+	conn, port := ntpTestServer(t, -time.Minute, 2)
+	defer conn.Close()
+	h := NewNTPHealthcheck(
+		zap.NewExample(),
+		&NTPHealthcheckConfiguration{
+			Target:    "127.0.0.1",
+			Port:      port,
+			MaxOffset: Duration(time.Second),
+			Timeout:   Duration(time.Second * 2),
+		})
+	err := h.Initialize()
+	if err != nil {
+		t.Fatalf("Initialization error :\n%v", err)
+	}
+	details, err := h.ExecuteWithDetails(context.Background())
+	if err == nil {
+		t.Fatalf("healthcheck was expected to fail")
+	}
+	if details["offset"].(float64) > -59 {
+		t.Fatalf("Invalid details: %v", details)
+	}
+}
+
+func TestNTPExecuteContext(t *testing.T) {
+	// this server never answers
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	if err != nil {
+		t.Fatalf("Fail to start the NTP server :\n%v", err)
+	}
+	defer conn.Close()
+	h := NewNTPHealthcheck(
+		zap.NewExample(),
+		&NTPHealthcheckConfiguration{
+			Target:  "127.0.0.1",
+			Port:    uint(conn.LocalAddr().(*net.UDPAddr).Port),
+			Timeout: Duration(time.Second * 10),
+		})
+	err = h.Initialize()
+	if err != nil {
+		t.Fatalf("Initialization error :\n%v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = h.Execute(ctx)
+	if err == nil {
+		t.Fatalf("healthcheck was expected to fail")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Invalid error: %v", err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Fatalf("The healthcheck did not honor the context deadline")
+	}
+}
+
+func TestNTPValidate(t *testing.T) {
+	cases := []NTPHealthcheckConfiguration{
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Timeout: Duration(time.Second)},
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Target: "127.0.0.1", Timeout: Duration(time.Second), MaxStratum: 16},
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Target: "127.0.0.1"},
+	}
+	for i := range cases {
+		err := cases[i].Validate()
+		if err == nil {
+			t.Fatalf("Validation was expected to fail for case %d", i)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/procfs"
+	"go.uber.org/zap"
+)
+
+// ProcessHealthcheckConfiguration defines a process healthcheck configuration
+type ProcessHealthcheckConfiguration struct {
+	Base `json:",inline" yaml:",inline"`
+	// the process command name, as shown in /proc/<pid>/comm
+	Command       string   `json:"command,omitempty"`
+	CmdlineRegexp []Regexp `json:"cmdline-regexp,omitempty" yaml:"cmdline-regexp,omitempty"`
+	MinInstances  uint     `json:"min-instances,omitempty" yaml:"min-instances,omitempty"`
+	MaxInstances  uint     `json:"max-instances,omitempty" yaml:"max-instances,omitempty"`
+	PIDFile       string   `json:"pid-file,omitempty" yaml:"pid-file,omitempty"`
+	MaxRSS        uint64   `json:"max-rss,omitempty" yaml:"max-rss,omitempty"`
+	MaxCPUTime    Duration `json:"max-cpu-time,omitempty" yaml:"max-cpu-time,omitempty"`
+	Timeout       Duration `json:"timeout"`
+}
+
+// ProcessHealthcheck defines a process healthcheck
+type ProcessHealthcheck struct {
+	Logger *zap.Logger
+	Config *ProcessHealthcheckConfiguration
+
+	fs procfs.FS
+}
+
+// Validate validates the healthcheck configuration
+func (config *ProcessHealthcheckConfiguration) Validate() error {
+	if err := config.Base.validate(); err != nil {
+		return err
+	}
+	if config.Command == "" && len(config.CmdlineRegexp) == 0 && config.PIDFile == "" {
+		return errors.New("The healthcheck command, cmdline regexp or pid file should be set")
+	}
+	if config.Timeout == 0 {
+		return errors.New("The healthcheck timeout is missing")
+	}
+	if config.MaxInstances != 0 && config.MaxInstances < config.MinInstances {
+		return errors.New("The healthcheck max instances should be greater than the min instances")
+	}
+	if err := config.Base.validateTimeout(config.Timeout); err != nil {
+		return err
+	}
+	if !config.Base.OneOff && config.Base.Schedule == "" {
+		if config.Base.Interval < Duration(2*time.Second) {
+			return errors.New("The healthcheck interval should be greater than 2 second")
+		}
+		if config.Base.Interval < config.Timeout {
+			return errors.New("The healthcheck interval should be greater than the timeout")
+		}
+	}
+	return nil
+}
+
+// Initialize the healthcheck.
+func (h *ProcessHealthcheck) Initialize() error {
+	fs, err := procfs.NewFS(procfs.DefaultMountPoint)
+	if err != nil {
+		return errors.Wrapf(err, "Fail to read %s", procfs.DefaultMountPoint)
+	}
+	h.fs = fs
+	return nil
+}
+
+// GetConfig get the config
+func (h *ProcessHealthcheck) GetConfig() interface{} {
+	return h.Config
+}
+
+// Base get the base configuration
+func (h *ProcessHealthcheck) Base() Base {
+	return h.Config.Base
+}
+
+// SetSource set the healthcheck source
+func (h *ProcessHealthcheck) SetSource(source string) {
+	h.Config.Base.Source = source
+}
+
+// Timeout returns the healthcheck timeout
+func (h *ProcessHealthcheck) Timeout() time.Duration {
+	return time.Duration(h.Config.Timeout)
+}
+
+// target returns a description of the processes verified by the healthcheck
+func (h *ProcessHealthcheck) target() string {
+	if h.Config.Command != "" {
+		return h.Config.Command
+	}
+	if len(h.Config.CmdlineRegexp) != 0 {
+		r := regexp.Regexp(h.Config.CmdlineRegexp[0])
+		return r.String()
+	}
+	return h.Config.PIDFile
+}
+
+// Summary returns an healthcheck summary
+func (h *ProcessHealthcheck) Summary() string {
+	summary := ""
+	if h.Config.Base.Description != "" {
+		summary = fmt.Sprintf("process healthcheck %s on %s", h.Config.Base.Description, h.target())
+
+	} else {
+		summary = fmt.Sprintf("process healthcheck on %s", h.target())
+	}
+
+	return summary
+}
+
+// LogError logs an error with context
+func (h *ProcessHealthcheck) LogError(err error, message string) {
+	h.Logger.Error(err.Error(),
+		zap.String("extra", message),
+		zap.String("process", h.target()),
+		zap.String("name", h.Config.Base.Name))
+}
+
+// LogDebug logs a message with context
+func (h *ProcessHealthcheck) LogDebug(message string) {
+	h.Logger.Debug(message,
+		zap.String("process", h.target()),
+		zap.String("name", h.Config.Base.Name))
+}
+
+// LogInfo logs a message with context
+func (h *ProcessHealthcheck) LogInfo(message string) {
+	h.Logger.Info(message,
+		zap.String("process", h.target()),
+		zap.String("name", h.Config.Base.Name))
+}
+
+// matches returns true if a process matches the configured command name and
+// cmdline regexps. Processes disappearing during the scan are ignored.
+func (h *ProcessHealthcheck) matches(proc procfs.Proc) bool {
+	if h.Config.Command != "" {
+		comm, err := proc.Comm()
+		if err != nil || comm != h.Config.Command {
+			return false
+		}
+	}
+	if len(h.Config.CmdlineRegexp) != 0 {
+		cmdline, err := proc.CmdLine()
+		if err != nil {
+			return false
+		}
+		line := strings.Join(cmdline, " ")
+		for _, regex := range h.Config.CmdlineRegexp {
+			r := regexp.Regexp(regex)
+			if !r.MatchString(line) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// readPIDFile returns the pid contained in the configured pid file
+func (h *ProcessHealthcheck) readPIDFile() (int, error) {
+	content, err := os.ReadFile(h.Config.PIDFile)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Fail to read the pid file %s", h.Config.PIDFile)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(content)))
+	if err != nil {
+		return 0, errors.Wrapf(err, "Invalid pid in %s", h.Config.PIDFile)
+	}
+	return pid, nil
+}
+
+// findProcesses returns the processes verified by the healthcheck
+func (h *ProcessHealthcheck) findProcesses(details map[string]interface{}) (procfs.Procs, error) {
+	if h.Config.PIDFile != "" {
+		pid, err := h.readPIDFile()
+		if err != nil {
+			return nil, err
+		}
+		details["pid-file-pid"] = pid
+		proc, err := h.fs.Proc(pid)
+		if err != nil {
+			return nil, fmt.Errorf("The process %d from %s is not running", pid, h.Config.PIDFile)
+		}
+		if !h.matches(proc) {
+			return nil, fmt.Errorf("The process %d from %s does not match the healthcheck", pid, h.Config.PIDFile)
+		}
+		if h.Config.Command == "" && len(h.Config.CmdlineRegexp) == 0 {
+			return procfs.Procs{proc}, nil
+		}
+	}
+	procs, err := h.fs.AllProcs()
+	if err != nil {
+		return nil, errors.Wrapf(err, "Fail to list processes")
+	}
+	result := procfs.Procs{}
+	for _, proc := range procs {
+		if h.matches(proc) {
+			result = append(result, proc)
+		}
+	}
+	return result, nil
+}
+
+// Execute executes an healthcheck on the given processes
+func (h *ProcessHealthcheck) Execute(ctx context.Context) error {
+	_, err := h.ExecuteWithDetails(ctx)
+	return err
+}
+
+// ExecuteWithDetails executes the healthcheck, returning the matching
+// processes and their resources usage as details
+func (h *ProcessHealthcheck) ExecuteWithDetails(ctx context.Context) (map[string]interface{}, error) {
+	h.LogDebug("start executing healthcheck")
+	details := map[string]interface{}{}
+	procs, err := h.findProcesses(details)
+	if err != nil {
+		return details, err
+	}
+	pids := make([]int, 0, len(procs))
+	maxRSS := 0
+	maxCPUTime := float64(0)
+	var resourceErr error
+	for _, proc := range procs {
+		stat, err := proc.Stat()
+		if err != nil {
+			// the process stopped
+			continue
+		}
+		pids = append(pids, proc.PID)
+		rss := stat.ResidentMemory()
+		if rss > maxRSS {
+			maxRSS = rss
+		}
+		cpuTime := stat.CPUTime()
+		if cpuTime > maxCPUTime {
+			maxCPUTime = cpuTime
+		}
+		if h.Config.MaxRSS != 0 && uint64(rss) > h.Config.MaxRSS {
+			resourceErr = fmt.Errorf("The process %d uses %d bytes of memory, greater than %d", proc.PID, rss, h.Config.MaxRSS)
+		}
+		if h.Config.MaxCPUTime != 0 && cpuTime > time.Duration(h.Config.MaxCPUTime).Seconds() {
+			resourceErr = fmt.Errorf("The process %d used %.2f seconds of CPU time, greater than %s", proc.PID, cpuTime, time.Duration(h.Config.MaxCPUTime).String())
+		}
+	}
+	details["instances"] = len(pids)
+	details["pids"] = pids
+	details["max-rss"] = maxRSS
+	details["max-cpu-time"] = maxCPUTime
+	minInstances := h.Config.MinInstances
+	if minInstances == 0 {
+		minInstances = 1
+	}
+	if uint(len(pids)) < minInstances {
+		return details, fmt.Errorf("%d processes found for %s, expected at least %d", len(pids), h.target(), minInstances)
+	}
+	if h.Config.MaxInstances != 0 && uint(len(pids)) > h.Config.MaxInstances {
+		return details, fmt.Errorf("%d processes found for %s, expected at most %d", len(pids), h.target(), h.Config.MaxInstances)
+	}
+	if resourceErr != nil {
+		return details, resourceErr
+	}
+	return details, nil
+}
+
+// NewProcessHealthcheck creates a process healthcheck from a logger and a configuration
+func NewProcessHealthcheck(logger *zap.Logger, config *ProcessHealthcheckConfiguration) *ProcessHealthcheck {
+	return &ProcessHealthcheck{
+		Logger: logger,
+		Config: config,
+	}
+}
+
+// MarshalJSON marshal to json a process healthcheck
+func (h *ProcessHealthcheck) MarshalJSON() ([]byte, error) {
+	return json.Marshal(h.Config)
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProcessHealthcheckConfiguration) DeepCopyInto(out *ProcessHealthcheckConfiguration) {
+	*out = *in
+	in.Base.DeepCopyInto(&out.Base)
+	if in.CmdlineRegexp != nil {
+		in, out := &in.CmdlineRegexp, &out.CmdlineRegexp
+		*out = make([]Regexp, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProcessHealthcheckConfiguration.
+func (in *ProcessHealthcheckConfiguration) DeepCopy() *ProcessHealthcheckConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(ProcessHealthcheckConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
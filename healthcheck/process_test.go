@@ -0,0 +1,121 @@
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func startSleep(t *testing.T, duration string) *exec.Cmd {
+	cmd := exec.Command("sleep", duration)
+	err := cmd.Start()
+	if err != nil {
+		t.Fatalf("Fail to start the process :\n%v", err)
+	}
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+	return cmd
+}
+
+func TestProcessExecuteSuccess(t *testing.T) {
+	startSleep(t, "31")
+	startSleep(t, "31")
+	r := regexp.MustCompile("^sleep 31$")
+	h := NewProcessHealthcheck(
+		zap.NewExample(),
+		&ProcessHealthcheckConfiguration{
+			Command:       "sleep",
+			CmdlineRegexp: []Regexp{Regexp(*r)},
+			MinInstances:  2,
+			MaxInstances:  2,
+			MaxRSS:        1024 * 1024 * 1024,
+			MaxCPUTime:    Duration(time.Minute),
+			Timeout:       Duration(time.Second * 2),
+		})
+	err := h.Initialize()
+	if err != nil {
+		t.Fatalf("Fail to initialize the healthcheck :\n%v", err)
+	}
+	details, err := h.ExecuteWithDetails(context.Background())
+	if err != nil {
+		t.Fatalf("healthcheck error :\n%v", err)
+	}
+	if details["instances"] != 2 {
+		t.Fatalf("Invalid details: %v", details)
+	}
+	h.Config.MaxInstances = 1
+	err = h.Execute(context.Background())
+	if err == nil {
+		t.Fatalf("healthcheck was expected to fail")
+	}
+	h.Config.MaxInstances = 0
+	h.Config.MinInstances = 3
+	err = h.Execute(context.Background())
+	if err == nil {
+		t.Fatalf("healthcheck was expected to fail")
+	}
+	h.Config.MinInstances = 0
+	h.Config.MaxRSS = 1
+	err = h.Execute(context.Background())
+	if err == nil {
+		t.Fatalf("healthcheck was expected to fail")
+	}
+}
+
+func TestProcessExecutePIDFile(t *testing.T) {
+	cmd := startSleep(t, "32")
+	path := filepath.Join(t.TempDir(), "sleep.pid")
+	err := os.WriteFile(path, []byte(fmt.Sprintf("%d\n", cmd.Process.Pid)), 0600)
+	if err != nil {
+		t.Fatalf("Fail to write the pid file :\n%v", err)
+	}
+	h := NewProcessHealthcheck(
+		zap.NewExample(),
+		&ProcessHealthcheckConfiguration{
+			PIDFile: path,
+			Timeout: Duration(time.Second * 2),
+		})
+	err = h.Initialize()
+	if err != nil {
+		t.Fatalf("Fail to initialize the healthcheck :\n%v", err)
+	}
+	err = h.Execute(context.Background())
+	if err != nil {
+		t.Fatalf("healthcheck error :\n%v", err)
+	}
+	h.Config.Command = "doesnotexist"
+	err = h.Execute(context.Background())
+	if err == nil {
+		t.Fatalf("healthcheck was expected to fail")
+	}
+	h.Config.Command = ""
+	_ = cmd.Process.Kill()
+	_ = cmd.Wait()
+	err = h.Execute(context.Background())
+	if err == nil {
+		t.Fatalf("healthcheck was expected to fail")
+	}
+}
+
+func TestProcessValidate(t *testing.T) {
+	cases := []ProcessHealthcheckConfiguration{
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Timeout: Duration(time.Second)},
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Command: "nginx"},
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Command: "nginx", Timeout: Duration(time.Second), MinInstances: 3, MaxInstances: 2},
+	}
+	for i := range cases {
+		err := cases[i].Validate()
+		if err == nil {
+			t.Fatalf("Validation was expected to fail for case %d", i)
+		}
+	}
+}
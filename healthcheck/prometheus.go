@@ -0,0 +1,370 @@
+package healthcheck
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"go.uber.org/zap"
+)
+
+const (
+	// ComparisonGreater the series value should be greater than the healthcheck value
+	ComparisonGreater = ">"
+	// ComparisonLower the series value should be lower than the healthcheck value
+	ComparisonLower = "<"
+	// ComparisonEqual the series value should be equal to the healthcheck value
+	ComparisonEqual = "=="
+	// ComparisonExists the series should exist
+	ComparisonExists = "exists"
+)
+
+// defaultPrometheusBodyLimit the default maximum size of the metrics page
+const defaultPrometheusBodyLimit = 10 * 1024 * 1024
+
+// PrometheusHealthcheckConfiguration defines a Prometheus metrics healthcheck configuration
+type PrometheusHealthcheckConfiguration struct {
+	Base `json:",inline" yaml:",inline"`
+	// can be an IP or a domain
+	Target     string            `json:"target"`
+	Host       string            `json:"host,omitempty"`
+	Port       uint              `json:"port"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Protocol   Protocol          `json:"protocol"`
+	Path       string            `json:"path,omitempty"`
+	SourceIP   IP                `json:"source-ip,omitempty" yaml:"source-ip,omitempty"`
+	Insecure   bool              `json:"insecure"`
+	ServerName string            `json:"server-name"`
+	Key        string            `json:"key,omitempty"`
+	Cert       string            `json:"cert,omitempty"`
+	Cacert     string            `json:"cacert,omitempty"`
+	Metric     string            `json:"metric"`
+	// labels the metric should have
+	MetricLabels map[string]string `json:"metric-labels,omitempty" yaml:"metric-labels,omitempty"`
+	Comparison   string            `json:"comparison"`
+	Value        float64           `json:"value"`
+	// maximum size of the metrics page in bytes
+	BodyLimit uint     `json:"body-limit,omitempty" yaml:"body-limit,omitempty"`
+	Timeout   Duration `json:"timeout"`
+}
+
+// Validate validates the healthcheck configuration
+func (config *PrometheusHealthcheckConfiguration) Validate() error {
+	if err := config.Base.validate(); err != nil {
+		return err
+	}
+	if config.Target == "" {
+		return errors.New("The healthcheck target is missing")
+	}
+	if config.Port == 0 {
+		return errors.New("The healthcheck port is missing")
+	}
+	if config.Timeout == 0 {
+		return errors.New("The healthcheck timeout is missing")
+	}
+	if config.Metric == "" {
+		return errors.New("The healthcheck metric is missing")
+	}
+	if config.Comparison != ComparisonGreater && config.Comparison != ComparisonLower && config.Comparison != ComparisonEqual && config.Comparison != ComparisonExists {
+		return fmt.Errorf("Invalid healthcheck comparison %s", config.Comparison)
+	}
+	if config.Path == "" {
+		config.Path = "/metrics"
+	}
+	if err := config.Base.validateTimeout(config.Timeout); err != nil {
+		return err
+	}
+	if !config.Base.OneOff && config.Base.Schedule == "" {
+		if config.Base.Interval < Duration(2*time.Second) {
+			return errors.New("The healthcheck interval should be greater than 2 second")
+		}
+		if config.Base.Interval < config.Timeout {
+			return errors.New("The healthcheck interval should be greater than the timeout")
+		}
+	}
+	if !((config.Key != "" && config.Cert != "") ||
+		(config.Key == "" && config.Cert == "")) {
+		return errors.New("Invalid certificates")
+	}
+	return nil
+}
+
+// PrometheusHealthcheck defines a Prometheus metrics healthcheck
+type PrometheusHealthcheck struct {
+	Logger *zap.Logger
+	Config *PrometheusHealthcheckConfiguration
+	URL    string
+
+	Client *http.Client
+}
+
+// Summary returns an healthcheck summary
+func (h *PrometheusHealthcheck) Summary() string {
+	summary := ""
+	if h.Config.Base.Description != "" {
+		summary = fmt.Sprintf("Prometheus healthcheck %s on %s:%d for %s", h.Config.Base.Description, h.Config.Target, h.Config.Port, h.Config.Metric)
+
+	} else {
+		summary = fmt.Sprintf("Prometheus healthcheck on %s:%d for %s", h.Config.Target, h.Config.Port, h.Config.Metric)
+	}
+
+	return summary
+}
+
+// Initialize the healthcheck.
+func (h *PrometheusHealthcheck) Initialize() error {
+	path := h.Config.Path
+	if path == "" {
+		path = "/metrics"
+	}
+	h.URL = buildHTTPURL(h.Config.Protocol, h.Config.Target, h.Config.Port, path)
+	client, err := newHTTPClient(h.Config.SourceIP, h.Config.Key, h.Config.Cert, h.Config.Cacert, h.Config.ServerName, h.Config.Insecure, true)
+	if err != nil {
+		return err
+	}
+	h.Client = client
+	return nil
+}
+
+// GetConfig get the config
+func (h *PrometheusHealthcheck) GetConfig() interface{} {
+	return h.Config
+}
+
+// Base get the base configuration
+func (h *PrometheusHealthcheck) Base() Base {
+	return h.Config.Base
+}
+
+// SetSource set the healthcheck source
+func (h *PrometheusHealthcheck) SetSource(source string) {
+	h.Config.Base.Source = source
+}
+
+// Timeout returns the healthcheck timeout
+func (h *PrometheusHealthcheck) Timeout() time.Duration {
+	return time.Duration(h.Config.Timeout)
+}
+
+// LogError logs an error with context
+func (h *PrometheusHealthcheck) LogError(err error, message string) {
+	h.Logger.Error(err.Error(),
+		zap.String("extra", message),
+		zap.String("target", h.Config.Target),
+		zap.Uint("port", h.Config.Port),
+		zap.String("metric", h.Config.Metric),
+		zap.String("name", h.Config.Base.Name))
+}
+
+// LogDebug logs a message with context
+func (h *PrometheusHealthcheck) LogDebug(message string) {
+	h.Logger.Debug(message,
+		zap.String("target", h.Config.Target),
+		zap.Uint("port", h.Config.Port),
+		zap.String("metric", h.Config.Metric),
+		zap.String("name", h.Config.Base.Name))
+}
+
+// LogInfo logs a message with context
+func (h *PrometheusHealthcheck) LogInfo(message string) {
+	h.Logger.Info(message,
+		zap.String("target", h.Config.Target),
+		zap.Uint("port", h.Config.Port),
+		zap.String("metric", h.Config.Metric),
+		zap.String("name", h.Config.Base.Name))
+}
+
+// matchLabels returns true if a metric has all the configured labels
+func (h *PrometheusHealthcheck) matchLabels(metric *dto.Metric) bool {
+	for name, value := range h.Config.MetricLabels {
+		found := false
+		for _, label := range metric.GetLabel() {
+			if label.GetName() == name && label.GetValue() == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// selectValues returns the values of the series matching the configured
+// metric name and labels. The _sum and _count series of summaries and
+// histograms are also supported.
+func (h *PrometheusHealthcheck) selectValues(families map[string]*dto.MetricFamily) []float64 {
+	name := h.Config.Metric
+	suffix := ""
+	family, ok := families[name]
+	if !ok {
+		for _, s := range []string{"_sum", "_count"} {
+			if strings.HasSuffix(name, s) {
+				family, ok = families[strings.TrimSuffix(name, s)]
+				suffix = s
+				break
+			}
+		}
+	}
+	if !ok {
+		return nil
+	}
+	values := []float64{}
+	for _, metric := range family.GetMetric() {
+		if !h.matchLabels(metric) {
+			continue
+		}
+		switch family.GetType() {
+		case dto.MetricType_COUNTER:
+			values = append(values, metric.GetCounter().GetValue())
+		case dto.MetricType_GAUGE:
+			values = append(values, metric.GetGauge().GetValue())
+		case dto.MetricType_UNTYPED:
+			values = append(values, metric.GetUntyped().GetValue())
+		case dto.MetricType_SUMMARY:
+			if suffix == "_sum" {
+				values = append(values, metric.GetSummary().GetSampleSum())
+			} else if suffix == "_count" {
+				values = append(values, float64(metric.GetSummary().GetSampleCount()))
+			}
+		case dto.MetricType_HISTOGRAM:
+			if suffix == "_sum" {
+				values = append(values, metric.GetHistogram().GetSampleSum())
+			} else if suffix == "_count" {
+				values = append(values, float64(metric.GetHistogram().GetSampleCount()))
+			}
+		}
+	}
+	return values
+}
+
+// compare verifies a series value against the configured comparison
+func (h *PrometheusHealthcheck) compare(value float64) bool {
+	switch h.Config.Comparison {
+	case ComparisonGreater:
+		return value > h.Config.Value
+	case ComparisonLower:
+		return value < h.Config.Value
+	case ComparisonEqual:
+		return value == h.Config.Value
+	}
+	return true
+}
+
+// Execute executes an healthcheck on the given target
+func (h *PrometheusHealthcheck) Execute(ctx context.Context) error {
+	_, err := h.ExecuteWithDetails(ctx)
+	return err
+}
+
+// ExecuteWithDetails executes the healthcheck, returning the values of the
+// selected series as details
+func (h *PrometheusHealthcheck) ExecuteWithDetails(ctx context.Context) (map[string]interface{}, error) {
+	h.LogDebug("start executing healthcheck")
+	req, err := http.NewRequestWithContext(ctx, "GET", h.URL, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fail to initialize HTTP request")
+	}
+	req.Header.Set("User-Agent", "Cabourotte")
+	req.Header.Set("Accept", string(expfmt.FmtText))
+	for k, v := range h.Config.Headers {
+		req.Header.Set(k, v)
+	}
+	if h.Config.Host != "" {
+		req.Host = h.Config.Host
+	}
+	response, err := h.Client.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "HTTP request failed")
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP request failed: status %d", response.StatusCode)
+	}
+	limit := int64(h.Config.BodyLimit)
+	if limit == 0 {
+		limit = defaultPrometheusBodyLimit
+	}
+	body, err := io.ReadAll(io.LimitReader(response.Body, limit+1))
+	if err != nil {
+		return nil, errors.Wrapf(err, "Fail to read the metrics")
+	}
+	if int64(len(body)) > limit {
+		return nil, fmt.Errorf("The metrics are larger than %d bytes", limit)
+	}
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrapf(err, "Fail to parse the metrics")
+	}
+	values := h.selectValues(families)
+	details := map[string]interface{}{
+		"values": values,
+	}
+	if len(values) == 0 {
+		return details, fmt.Errorf("No series found for metric %s", h.Config.Metric)
+	}
+	for _, value := range values {
+		if !h.compare(value) {
+			return details, fmt.Errorf("The metric %s value is %g, expected %s %g", h.Config.Metric, value, h.Config.Comparison, h.Config.Value)
+		}
+	}
+	return details, nil
+}
+
+// NewPrometheusHealthcheck creates a Prometheus metrics healthcheck from a logger and a configuration
+func NewPrometheusHealthcheck(logger *zap.Logger, config *PrometheusHealthcheckConfiguration) *PrometheusHealthcheck {
+	return &PrometheusHealthcheck{
+		Logger: logger,
+		Config: config,
+	}
+}
+
+// MarshalJSON marshal to json a Prometheus metrics healthcheck
+func (h *PrometheusHealthcheck) MarshalJSON() ([]byte, error) {
+	return json.Marshal(h.Config)
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PrometheusHealthcheckConfiguration) DeepCopyInto(out *PrometheusHealthcheckConfiguration) {
+	*out = *in
+	in.Base.DeepCopyInto(&out.Base)
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.SourceIP != nil {
+		in, out := &in.SourceIP, &out.SourceIP
+		*out = make(IP, len(*in))
+		copy(*out, *in)
+	}
+	if in.MetricLabels != nil {
+		in, out := &in.MetricLabels, &out.MetricLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrometheusHealthcheckConfiguration.
+func (in *PrometheusHealthcheckConfiguration) DeepCopy() *PrometheusHealthcheckConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(PrometheusHealthcheckConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
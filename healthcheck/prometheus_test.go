@@ -0,0 +1,141 @@
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v2"
+)
+
+const prometheusTestMetrics = `# HELP queue_depth The queue depth
+# TYPE queue_depth gauge
+queue_depth{queue="jobs"} 12
+queue_depth{queue="mails"} 3
+# HELP up The application status
+# TYPE up untyped
+up 1
+# HELP request_duration_seconds The request duration
+# TYPE request_duration_seconds histogram
+request_duration_seconds_bucket{le="1"} 4
+request_duration_seconds_bucket{le="+Inf"} 5
+request_duration_seconds_sum 3.5
+request_duration_seconds_count 5
+`
+
+func TestPrometheusExecute(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/metrics" || r.Header.Get("Foo") != "Bar" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, err := w.Write([]byte(prometheusTestMetrics))
+		if err != nil {
+			t.Fatalf("Error writing :\n%v", err)
+		}
+	}))
+	defer ts.Close()
+
+	port, err := strconv.ParseUint(strings.Split(ts.URL, ":")[2], 10, 16)
+	if err != nil {
+		t.Fatalf("error getting HTTP server port :\n%v", err)
+	}
+	cases := []struct {
+		metric     string
+		labels     map[string]string
+		comparison string
+		value      float64
+		bodyLimit  uint
+		success    bool
+	}{
+		{metric: "up", comparison: ComparisonEqual, value: 1, success: true},
+		{metric: "up", comparison: ComparisonEqual, value: 0, success: false},
+		{metric: "queue_depth", labels: map[string]string{"queue": "jobs"}, comparison: ComparisonLower, value: 20, success: true},
+		{metric: "queue_depth", labels: map[string]string{"queue": "jobs"}, comparison: ComparisonLower, value: 10, success: false},
+		{metric: "queue_depth", comparison: ComparisonGreater, value: 5, success: false},
+		{metric: "queue_depth", labels: map[string]string{"queue": "mails"}, comparison: ComparisonExists, success: true},
+		{metric: "queue_depth", labels: map[string]string{"queue": "sms"}, comparison: ComparisonExists, success: false},
+		{metric: "request_duration_seconds_count", comparison: ComparisonEqual, value: 5, success: true},
+		{metric: "doesnotexist", comparison: ComparisonExists, success: false},
+		{metric: "up", comparison: ComparisonEqual, value: 1, bodyLimit: uint(len(prometheusTestMetrics)), success: true},
+		{metric: "up", comparison: ComparisonEqual, value: 1, bodyLimit: 64, success: false},
+	}
+	for i, c := range cases {
+		h := NewPrometheusHealthcheck(
+			zap.NewExample(),
+			&PrometheusHealthcheckConfiguration{
+				Target:       "127.0.0.1",
+				Port:         uint(port),
+				Protocol:     HTTP,
+				Headers:      map[string]string{"Foo": "Bar"},
+				Metric:       c.metric,
+				MetricLabels: c.labels,
+				Comparison:   c.comparison,
+				Value:        c.value,
+				BodyLimit:    c.bodyLimit,
+				Timeout:      Duration(time.Second * 2),
+			})
+		err = h.Initialize()
+		if err != nil {
+			t.Fatalf("Initialization error :\n%v", err)
+		}
+		err = h.Execute(context.Background())
+		if c.success && err != nil {
+			t.Fatalf("healthcheck error for case %d :\n%v", i, err)
+		}
+		if !c.success && err == nil {
+			t.Fatalf("healthcheck was expected to fail for case %d", i)
+		}
+	}
+}
+
+func TestPrometheusValidate(t *testing.T) {
+	cases := []PrometheusHealthcheckConfiguration{
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Target: "127.0.0.1", Port: 9090, Timeout: Duration(time.Second), Comparison: ComparisonExists},
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Target: "127.0.0.1", Port: 9090, Timeout: Duration(time.Second), Metric: "up", Comparison: ">="},
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Port: 9090, Timeout: Duration(time.Second), Metric: "up", Comparison: ComparisonExists},
+	}
+	for i := range cases {
+		err := cases[i].Validate()
+		if err == nil {
+			t.Fatalf("Validation was expected to fail for case %d", i)
+		}
+	}
+}
+
+func TestPrometheusUnmarshalLabels(t *testing.T) {
+	yamlConfig := `
+name: foo
+labels:
+  env: prod
+metric: queue_depth
+metric-labels:
+  queue: jobs
+`
+	jsonConfig := `{"name":"foo","labels":{"env":"prod"},"metric":"queue_depth","metric-labels":{"queue":"jobs"}}`
+	var fromYAML PrometheusHealthcheckConfiguration
+	err := yaml.UnmarshalStrict([]byte(yamlConfig), &fromYAML)
+	if err != nil {
+		t.Fatalf("Unmarshal error :\n%v", err)
+	}
+	var fromJSON PrometheusHealthcheckConfiguration
+	err = json.Unmarshal([]byte(jsonConfig), &fromJSON)
+	if err != nil {
+		t.Fatalf("Unmarshal error :\n%v", err)
+	}
+	for _, config := range []PrometheusHealthcheckConfiguration{fromYAML, fromJSON} {
+		if config.Base.Labels["env"] != "prod" || len(config.Base.Labels) != 1 {
+			t.Fatalf("Invalid healthcheck labels: %v", config.Base.Labels)
+		}
+		if config.MetricLabels["queue"] != "jobs" || len(config.MetricLabels) != 1 {
+			t.Fatalf("Invalid metric labels: %v", config.MetricLabels)
+		}
+	}
+}
@@ -1,19 +1,86 @@
 package healthcheck
 
 import (
+	"fmt"
+	"reflect"
 	"time"
+
+	"github.com/pkg/errors"
 )
 
+// Status is the status of an healthcheck result
+type Status string
+
+const (
+	// StatusOK the healthcheck is successful
+	StatusOK Status = "ok"
+	// StatusWarning the healthcheck is successful but degraded
+	StatusWarning Status = "warning"
+	// StatusCritical the healthcheck failed
+	StatusCritical Status = "critical"
+	// StatusUnknown the healthcheck could not determine the state of its
+	// target
+	StatusUnknown Status = "unknown"
+)
+
+// Success returns true if the status is considered as successful
+func (s Status) Success() bool {
+	return s == StatusOK || s == StatusWarning
+}
+
+// StatusError is an error carrying the status of the healthcheck result.
+// Errors without status produce critical results.
+type StatusError struct {
+	Status Status
+	Err    error
+}
+
+// Error returns the error message
+func (e *StatusError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *StatusError) Unwrap() error {
+	return e.Err
+}
+
+// NewStatusError creates an error producing a result with the given status
+func NewStatusError(status Status, err error) error {
+	return &StatusError{
+		Status: status,
+		Err:    err,
+	}
+}
+
+// ErrorStatus returns the status of the result produced by an error
+func ErrorStatus(err error) Status {
+	if err == nil {
+		return StatusOK
+	}
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Status
+	}
+	return StatusCritical
+}
+
 // Result represents the result of an healthcheck
 type Result struct {
-	Name                 string            `json:"name"`
-	Summary              interface{}       `json:"summary"`
-	Labels               map[string]string `json:"labels,omitempty"`
-	Success              bool              `json:"success"`
-	HealthcheckTimestamp int64             `json:"healthcheck-timestamp"`
-	Message              string            `json:"message"`
-	Duration             int64             `json:"duration"`
-	Source               string            `json:"source"`
+	Name                 string                 `json:"name"`
+	Summary              interface{}            `json:"summary"`
+	Labels               map[string]string      `json:"labels,omitempty"`
+	Success              bool                   `json:"success"`
+	Status               Status                 `json:"status"`
+	RawStatus            Status                 `json:"raw-status,omitempty"`
+	Flapping             bool                   `json:"flapping,omitempty"`
+	Silenced             bool                   `json:"silenced,omitempty"`
+	HealthcheckTimestamp int64                  `json:"healthcheck-timestamp"`
+	Message              string                 `json:"message"`
+	Duration             int64                  `json:"duration"`
+	Source               string                 `json:"source"`
+	Details              map[string]interface{} `json:"details,omitempty"`
+	Attempts             int                    `json:"attempts,omitempty"`
 }
 
 // Equals implements Equals for Result
@@ -27,6 +94,18 @@ func (r Result) Equals(v Result) bool {
 	if r.Success != v.Success {
 		return false
 	}
+	if r.Status != v.Status {
+		return false
+	}
+	if r.RawStatus != v.RawStatus {
+		return false
+	}
+	if r.Flapping != v.Flapping {
+		return false
+	}
+	if r.Silenced != v.Silenced {
+		return false
+	}
 	if r.HealthcheckTimestamp != v.HealthcheckTimestamp {
 		return false
 	}
@@ -47,6 +126,12 @@ func (r Result) Equals(v Result) bool {
 			return false
 		}
 	}
+	if !reflect.DeepEqual(r.Details, v.Details) {
+		return false
+	}
+	if r.Attempts != v.Attempts {
+		return false
+	}
 	return true
 }
 
@@ -57,20 +142,42 @@ func NewResult(healthcheck Healthcheck, duration int64, err error) *Result {
 	if healthcheck.Base().Source != "" {
 		source = healthcheck.Base().Source
 	}
+	summary := healthcheck.Summary()
+	if healthcheck.Base().ShouldFail {
+		summary = summary + ". This healthcheck has should-fail=true."
+	}
 	result := Result{
 		Name:                 healthcheck.Base().Name,
-		Summary:              healthcheck.Summary(),
+		Summary:              summary,
 		Labels:               healthcheck.Base().Labels,
 		HealthcheckTimestamp: now.Unix(),
 		Duration:             duration,
 		Source:               source,
 	}
+	result.Status = ErrorStatus(err)
+	result.Success = result.Status.Success()
 	if err != nil {
-		result.Success = false
 		result.Message = err.Error()
 	} else {
-		result.Success = true
 		result.Message = "success"
 	}
 	return &result
 }
+
+// checkDuration degrades a successful result if the healthcheck execution
+// exceeded the durations thresholds
+func (r *Result) checkDuration(base Base, duration time.Duration) {
+	if !r.Success {
+		return
+	}
+	if base.CriticalDuration != 0 && duration >= time.Duration(base.CriticalDuration) {
+		r.Success = false
+		r.Status = StatusCritical
+		r.Message = fmt.Sprintf("The healthcheck took %s, more than the critical duration %s", duration.String(), time.Duration(base.CriticalDuration).String())
+		return
+	}
+	if r.Status == StatusOK && base.WarningDuration != 0 && duration >= time.Duration(base.WarningDuration) {
+		r.Status = StatusWarning
+		r.Message = fmt.Sprintf("The healthcheck took %s, more than the warning duration %s", duration.String(), time.Duration(base.WarningDuration).String())
+	}
+}
@@ -1,6 +1,7 @@
 package healthcheck
 
 import (
+	"context"
 	"fmt"
 	"math/rand"
 	"reflect"
@@ -11,10 +12,15 @@ import (
 	"github.com/pkg/errors"
 	prom "github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
+	"gopkg.in/tomb.v2"
 
 	"github.com/appclacks/cabourotte/prometheus"
 )
 
+// runChecksConcurrency is the maximum number of healthchecks executed at the
+// same time by RunChecks
+const runChecksConcurrency = 10
+
 // HealthcheckConfiguration is the interface for the healthcheck configuration
 type HealthcheckConfiguration interface {
 	Validate() error
@@ -25,7 +31,8 @@ type Healthcheck interface {
 	Initialize() error
 	GetConfig() interface{}
 	Summary() string
-	Execute() error
+	Timeout() time.Duration
+	Execute(ctx context.Context) error
 	LogDebug(message string)
 	LogInfo(message string)
 	Base() Base
@@ -33,63 +40,184 @@ type Healthcheck interface {
 	LogError(err error, message string)
 }
 
+// DetailedHealthcheck is implemented by healthchecks reporting details about
+// their execution. These details are added to the healthcheck result.
+type DetailedHealthcheck interface {
+	ExecuteWithDetails(ctx context.Context) (map[string]interface{}, error)
+}
+
+// ClosableHealthcheck is implemented by healthchecks keeping resources, like
+// connections, between executions. Close is called when the healthcheck is
+// stopped.
+type ClosableHealthcheck interface {
+	Close() error
+}
+
+// GaugesHealthcheck is implemented by healthchecks exposing gauges computed
+// from their results. The gauges are identified by their Prometheus names
+// and are registered by the component.
+type GaugesHealthcheck interface {
+	Gauges(result *Result) map[string]float64
+}
+
+// execute executes an healthcheck, returning its details if the healthcheck
+// reports some
+func execute(ctx context.Context, healthcheck Healthcheck) (map[string]interface{}, error) {
+	if detailed, ok := healthcheck.(DetailedHealthcheck); ok {
+		return detailed.ExecuteWithDetails(ctx)
+	}
+	return nil, healthcheck.Execute(ctx)
+}
+
+// Execute executes an healthcheck, inverting its result if the healthcheck
+// is expected to fail. The healthcheck should stop when the context is
+// cancelled.
+func Execute(ctx context.Context, healthcheck Healthcheck) (map[string]interface{}, error) {
+	details, err := execute(ctx, healthcheck)
+	return invert(healthcheck, details, err)
+}
+
+// invert inverts the result of an healthcheck expected to fail. Only
+// warnings and critical failures are inverted: unknown results and
+// executions which were cancelled or aborted by the wrapper are returned
+// unchanged.
+func invert(healthcheck Healthcheck, details map[string]interface{}, err error) (map[string]interface{}, error) {
+	if !healthcheck.Base().ShouldFail {
+		return details, err
+	}
+	status := ErrorStatus(err)
+	if status == StatusOK {
+		return details, errors.New("The healthcheck is successful but an error was expected")
+	}
+	var aborted *abortedError
+	if (status != StatusWarning && status != StatusCritical) || errors.Is(err, context.Canceled) || errors.As(err, &aborted) {
+		return details, err
+	}
+	if details == nil {
+		details = map[string]interface{}{}
+	}
+	details["expected-error"] = err.Error()
+	return details, nil
+}
+
 // Component is the component which will manage healthchecks
 type Component struct {
 	Logger             *zap.Logger
 	Healthchecks       map[string]*Wrapper
+	Silences           *Silences
 	resultHistogram    *prom.HistogramVec
 	resultCounter      *prom.CounterVec
+	attemptsCounter    *prom.CounterVec
+	flappingGauge      *prom.GaugeVec
+	pausedGauge        *prom.GaugeVec
+	checkGauges        map[string]*prom.GaugeVec
 	lock               sync.RWMutex
 	healthchecksLabels []string
 
 	ChanResult chan *Result
 }
 
-// Start an healthcheck wrapper
-func (c *Component) startWrapper(w *Wrapper) {
+// Start an healthcheck wrapper. A resumed healthcheck is executed
+// immediately.
+func (c *Component) startWrapper(w *Wrapper, resumed bool) {
 	w.healthcheck.LogInfo("Starting healthcheck")
-	w.Tick = time.NewTicker(time.Duration(w.healthcheck.Base().Interval))
-	w.t.Go(func() error {
-		wait := rand.Intn(4000)
-		time.Sleep(time.Duration(wait) * time.Millisecond)
-		for {
-			start := time.Now()
-			err := w.healthcheck.Execute()
-			duration := time.Since(start)
-			result := NewResult(
-				w.healthcheck,
-				duration.Milliseconds(),
-				err)
-			status := "failure"
-			if result.Success {
-				status = "success"
-			}
-			histoLabels := map[string]string{
-				"name": w.healthcheck.Base().Name,
-			}
-			for _, k := range c.healthchecksLabels {
-				histoLabels[k] = result.Labels[k]
+	t := new(tomb.Tomb)
+	w.lock.Lock()
+	w.t = t
+	w.lock.Unlock()
+	w.Timer = time.NewTimer(time.Duration(w.healthcheck.Base().Interval))
+	t.Go(func() error {
+		if !resumed && w.schedule != nil {
+			// wait for the first scheduled execution
+			w.resetTimer(0, time.Now())
+			select {
+			case <-w.Timer.C:
+			case <-t.Dying():
+				return nil
 			}
-			c.resultHistogram.With(prom.Labels(histoLabels)).Observe(duration.Seconds())
-			counterLabels := map[string]string{
-				"name":   w.healthcheck.Base().Name,
-				"status": status,
+		} else if !resumed {
+			wait := rand.Intn(4000)
+			select {
+			case <-time.After(time.Duration(wait) * time.Millisecond):
+			case <-t.Dying():
+				return nil
 			}
-			for _, k := range c.healthchecksLabels {
-				counterLabels[k] = result.Labels[k]
+		}
+		for {
+			start := time.Now()
+			base := w.healthcheck.Base()
+			if base.active(start) {
+				result, duration := w.run(t.Dying())
+				c.handleResult(w, result, duration)
+			} else {
+				w.healthcheck.LogDebug("outside of the active windows, skipping execution")
 			}
-			c.resultCounter.With(prom.Labels(counterLabels)).Inc()
-			c.ChanResult <- result
+			w.resetTimer(w.currentInterval(), start)
 			select {
-			case <-w.Tick.C:
+			case <-w.Timer.C:
 				continue
-			case <-w.t.Dying():
+			case <-t.Dying():
 				return nil
 			}
 		}
 	})
 }
 
+// handleResult updates the metrics and sends the result of an healthcheck
+// execution to the result channel. The result of an healthcheck removed
+// during its execution is dropped, its metrics are not recreated.
+func (c *Component) handleResult(w *Wrapper, result *Result, duration time.Duration) {
+	w.lock.RLock()
+	if w.ctx.Err() != nil {
+		w.lock.RUnlock()
+		w.healthcheck.LogDebug("the healthcheck was removed, dropping its result")
+		return
+	}
+	// the status label keeps its success and failure values, the detailed
+	// status is exposed by the severity label
+	status := "failure"
+	if result.RawStatus.Success() {
+		status = "success"
+	}
+	histoLabels := map[string]string{
+		"name": w.healthcheck.Base().Name,
+	}
+	for _, k := range c.healthchecksLabels {
+		histoLabels[k] = result.Labels[k]
+	}
+	c.resultHistogram.With(prom.Labels(histoLabels)).Observe(duration.Seconds())
+	counterLabels := map[string]string{
+		"name":     w.healthcheck.Base().Name,
+		"status":   status,
+		"severity": string(result.RawStatus),
+	}
+	for _, k := range c.healthchecksLabels {
+		counterLabels[k] = result.Labels[k]
+	}
+	c.resultCounter.With(prom.Labels(counterLabels)).Inc()
+	c.attemptsCounter.With(prom.Labels(histoLabels)).Add(float64(result.Attempts))
+	flapping := 0.0
+	if result.Flapping {
+		flapping = 1
+	}
+	c.flappingGauge.With(prom.Labels(histoLabels)).Set(flapping)
+	result.Silenced = c.Silences.Silenced(result.Name, result.Labels, time.Now())
+	if check, ok := w.healthcheck.(GaugesHealthcheck); ok {
+		c.updateCheckGauges(histoLabels, check.Gauges(result))
+	}
+	w.lock.RUnlock()
+	c.ChanResult <- result
+}
+
+// updateCheckGauges sets the gauges exposed by an healthcheck
+func (c *Component) updateCheckGauges(labels map[string]string, gauges map[string]float64) {
+	for name, value := range gauges {
+		if gauge, ok := c.checkGauges[name]; ok {
+			gauge.With(prom.Labels(labels)).Set(value)
+		}
+	}
+}
+
 // New creates a new Healthcheck component
 func New(logger *zap.Logger, chanResult chan *Result, promComponent *prometheus.Prometheus, healthchecksLabels []string) (*Component, error) {
 	buckets := []float64{
@@ -104,14 +232,44 @@ func New(logger *zap.Logger, chanResult chan *Result, promComponent *prometheus.
 	},
 		histoLabels,
 	)
-	counterLabels := []string{"name", "status"}
+	counterLabels := []string{"name", "status", "severity"}
 	counterLabels = append(counterLabels, healthchecksLabels...)
 	counter := prom.NewCounterVec(
 		prom.CounterOpts{
 			Name: "healthcheck_total",
-			Help: "Count the number of healthchecks executions.",
+			Help: "Count the number of healthchecks executions. The status label is success or failure, the severity label is the result status: ok, warning, critical or unknown.",
 		},
 		counterLabels)
+	attempts := prom.NewCounterVec(
+		prom.CounterOpts{
+			Name: "healthcheck_attempts_total",
+			Help: "Count the number of healthchecks execution attempts, retries included.",
+		},
+		histoLabels)
+	flapping := prom.NewGaugeVec(
+		prom.GaugeOpts{
+			Name: "healthcheck_flapping",
+			Help: "Set to 1 if the healthcheck status is flapping.",
+		},
+		histoLabels)
+	paused := prom.NewGaugeVec(
+		prom.GaugeOpts{
+			Name: "healthcheck_paused",
+			Help: "Set to 1 if the healthcheck is paused.",
+		},
+		histoLabels)
+	ntpOffset := prom.NewGaugeVec(
+		prom.GaugeOpts{
+			Name: ntpOffsetGauge,
+			Help: "Clock offset reported by the NTP healthchecks.",
+		},
+		histoLabels)
+	ntpDelay := prom.NewGaugeVec(
+		prom.GaugeOpts{
+			Name: ntpDelayGauge,
+			Help: "Round trip delay reported by the NTP healthchecks.",
+		},
+		histoLabels)
 
 	err := promComponent.Register(histo)
 	if err != nil {
@@ -121,11 +279,39 @@ func New(logger *zap.Logger, chanResult chan *Result, promComponent *prometheus.
 	if err != nil {
 		return nil, errors.Wrapf(err, "fail to register the healthcheck results Prometheus counter")
 	}
+	err = promComponent.Register(attempts)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fail to register the healthcheck attempts Prometheus counter")
+	}
+	err = promComponent.Register(flapping)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fail to register the healthcheck flapping Prometheus gauge")
+	}
+	err = promComponent.Register(paused)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fail to register the healthcheck paused Prometheus gauge")
+	}
+	err = promComponent.Register(ntpOffset)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fail to register the NTP offset Prometheus gauge")
+	}
+	err = promComponent.Register(ntpDelay)
+	if err != nil {
+		return nil, errors.Wrapf(err, "fail to register the NTP delay Prometheus gauge")
+	}
 	component := Component{
-		resultCounter:      counter,
-		resultHistogram:    histo,
+		resultCounter:   counter,
+		resultHistogram: histo,
+		attemptsCounter: attempts,
+		flappingGauge:   flapping,
+		pausedGauge:     paused,
+		checkGauges: map[string]*prom.GaugeVec{
+			ntpOffsetGauge: ntpOffset,
+			ntpDelayGauge:  ntpDelay,
+		},
 		Logger:             logger,
 		Healthchecks:       make(map[string]*Wrapper),
+		Silences:           NewSilences(),
 		ChanResult:         chanResult,
 		healthchecksLabels: healthchecksLabels,
 	}
@@ -163,12 +349,18 @@ func (c *Component) Stop() error {
 func (c *Component) removeCheck(identifier string) error {
 	if existingWrapper, ok := c.Healthchecks[identifier]; ok {
 		existingWrapper.healthcheck.LogInfo("Stopping healthcheck")
-		c.resultHistogram.DeletePartialMatch(prom.Labels{"name": identifier})
-		c.resultCounter.DeletePartialMatch(prom.Labels{"name": identifier})
 		err := existingWrapper.Stop()
 		if err != nil {
 			return errors.Wrapf(err, "Fail to stop healthcheck %s", existingWrapper.healthcheck.Base().Name)
 		}
+		c.resultHistogram.DeletePartialMatch(prom.Labels{"name": identifier})
+		c.resultCounter.DeletePartialMatch(prom.Labels{"name": identifier})
+		c.attemptsCounter.DeletePartialMatch(prom.Labels{"name": identifier})
+		c.flappingGauge.DeletePartialMatch(prom.Labels{"name": identifier})
+		c.pausedGauge.DeletePartialMatch(prom.Labels{"name": identifier})
+		for _, gauge := range c.checkGauges {
+			gauge.DeletePartialMatch(prom.Labels{"name": identifier})
+		}
 		delete(c.Healthchecks, identifier)
 		existingWrapper.healthcheck.LogInfo("Healthcheck stopped")
 	}
@@ -191,18 +383,139 @@ func (c *Component) AddCheck(check Healthcheck) error {
 	if err != nil {
 		return errors.Wrapf(err, "Fail to initialize healthcheck %s", wrapper.healthcheck.Base().Name)
 	}
+	base := wrapper.healthcheck.Base()
+	schedule, err := base.cronSchedule()
+	if err != nil {
+		return errors.Wrapf(err, "Fail to initialize healthcheck %s", wrapper.healthcheck.Base().Name)
+	}
+	wrapper.schedule = schedule
 
 	// verifies if the healthcheck already exists, and removes it if needed.
 	// Updating an healthcheck is removing the old one and adding the new one.
+	// The new healthcheck stays paused if the old one was.
+	if currentCheck, ok := c.Healthchecks[wrapper.healthcheck.Base().Name]; ok {
+		wrapper.paused = currentCheck.Paused()
+	}
 	err = c.removeCheck(wrapper.healthcheck.Base().Name)
 	if err != nil {
 		return errors.Wrapf(err, "Fail to stop existing healthcheck %s", wrapper.healthcheck.Base().Name)
 	}
-	c.startWrapper(wrapper)
+	if !wrapper.paused {
+		c.startWrapper(wrapper, false)
+	}
 	c.Healthchecks[wrapper.healthcheck.Base().Name] = wrapper
+	c.updatePausedGauge(wrapper)
 	return nil
 }
 
+// updatePausedGauge exposes if the healthcheck is paused
+func (c *Component) updatePausedGauge(w *Wrapper) {
+	base := w.healthcheck.Base()
+	labels := map[string]string{
+		"name": base.Name,
+	}
+	for _, k := range c.healthchecksLabels {
+		labels[k] = base.Labels[k]
+	}
+	paused := 0.0
+	if w.Paused() {
+		paused = 1
+	}
+	c.pausedGauge.With(prom.Labels(labels)).Set(paused)
+}
+
+// PauseCheck stops the executions of an healthcheck, keeping its definition.
+// An execution in progress is not interrupted.
+func (c *Component) PauseCheck(name string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	wrapper, ok := c.Healthchecks[name]
+	if !ok {
+		return fmt.Errorf("Healthcheck %s not found", name)
+	}
+	if wrapper.Paused() {
+		return nil
+	}
+	wrapper.healthcheck.LogInfo("Pausing healthcheck")
+	err := wrapper.stopLoop()
+	if err != nil {
+		return errors.Wrapf(err, "Fail to pause healthcheck %s", name)
+	}
+	wrapper.setPaused(true)
+	c.updatePausedGauge(wrapper)
+	return nil
+}
+
+// ResumeCheck restarts the execution of a paused healthcheck
+func (c *Component) ResumeCheck(name string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	wrapper, ok := c.Healthchecks[name]
+	if !ok {
+		return fmt.Errorf("Healthcheck %s not found", name)
+	}
+	if !wrapper.Paused() {
+		return nil
+	}
+	wrapper.healthcheck.LogInfo("Resuming healthcheck")
+	wrapper.setPaused(false)
+	c.startWrapper(wrapper, true)
+	c.updatePausedGauge(wrapper)
+	return nil
+}
+
+// RunCheck executes an healthcheck immediately, outside of its schedule and
+// without delaying its next execution. The result is sent to the result
+// channel and returned.
+func (c *Component) RunCheck(name string) (*Result, error) {
+	c.lock.RLock()
+	wrapper, ok := c.Healthchecks[name]
+	c.lock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("Healthcheck %s not found", name)
+	}
+	return c.runWrapper(wrapper), nil
+}
+
+// RunChecks executes immediately the healthchecks having all the labels of
+// the selector, returning the results sorted by name. At most
+// runChecksConcurrency healthchecks are executed at the same time.
+func (c *Component) RunChecks(selector map[string]string) []*Result {
+	c.lock.RLock()
+	wrappers := []*Wrapper{}
+	for _, wrapper := range c.Healthchecks {
+		if matchLabels(selector, wrapper.healthcheck.Base().Labels) {
+			wrappers = append(wrappers, wrapper)
+		}
+	}
+	c.lock.RUnlock()
+	results := make([]*Result, len(wrappers))
+	semaphore := make(chan struct{}, runChecksConcurrency)
+	var wg sync.WaitGroup
+	for i := range wrappers {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			results[i] = c.runWrapper(wrappers[i])
+			<-semaphore
+		}(i)
+	}
+	wg.Wait()
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Name < results[j].Name
+	})
+	return results
+}
+
+// runWrapper executes an healthcheck on demand
+func (c *Component) runWrapper(w *Wrapper) *Result {
+	w.healthcheck.LogInfo("Executing healthcheck on demand")
+	result, duration := w.run(nil)
+	c.handleResult(w, result, duration)
+	return result
+}
+
 // RemoveCheck Removes an healthcheck
 func (c *Component) RemoveCheck(name string) error {
 	c.lock.Lock()
@@ -226,6 +539,18 @@ func (c *Component) ListChecks() []Healthcheck {
 	return result
 }
 
+// RuntimeStates returns the runtime state of the healthchecks currently
+// configured, by name
+func (c *Component) RuntimeStates() map[string]*RuntimeState {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	result := make(map[string]*RuntimeState, len(c.Healthchecks))
+	for name, wrapper := range c.Healthchecks {
+		result[name] = wrapper.RuntimeState()
+	}
+	return result
+}
+
 // GetCheck returns a check if it exists, otherwise an error.
 func (c *Component) GetCheck(name string) Healthcheck {
 	c.lock.RLock()
@@ -258,6 +583,17 @@ func (c *Component) RemoveNonConfiguredHealthchecks(oldChecks map[string]bool, n
 	return nil
 }
 
+// matchLabels returns true if the labels contain all the selector labels
+func matchLabels(selector map[string]string, labels map[string]string) bool {
+	for k, v := range selector {
+		value, ok := labels[k]
+		if !ok || value != v {
+			return false
+		}
+	}
+	return true
+}
+
 // MergeLabels merge labels from a base and a map of string
 func MergeLabels(base *Base, new map[string]string) {
 	if new != nil && base.Labels != nil {
@@ -278,7 +614,18 @@ func (c *Component) ReloadForSource(
 	dns []DNSHealthcheckConfiguration,
 	tcp []TCPHealthcheckConfiguration,
 	http []HTTPHealthcheckConfiguration,
-	tls []TLSHealthcheckConfiguration) error {
+	tls []TLSHealthcheckConfiguration,
+	file []FileHealthcheckConfiguration,
+	process []ProcessHealthcheckConfiguration,
+	host []HostHealthcheckConfiguration,
+	prometheus []PrometheusHealthcheckConfiguration,
+	websocket []WebSocketHealthcheckConfiguration,
+	mqtt []MQTTHealthcheckConfiguration,
+	ldap []LDAPHealthcheckConfiguration,
+	ssh []SSHHealthcheckConfiguration,
+	ntp []NTPHealthcheckConfiguration,
+	snmp []SNMPHealthcheckConfiguration,
+	docker []DockerHealthcheckConfiguration) error {
 
 	oldChecks := c.SourceChecksNames(source)
 	newChecks := make(map[string]bool)
@@ -357,5 +704,170 @@ func (c *Component) ReloadForSource(
 			return errors.Wrapf(err, "Fail to add healthcheck %s", newCheck.Base().Name)
 		}
 	}
+	for i := range file {
+		config := &file[i]
+		MergeLabels(&config.Base, commonLabels)
+		config.Base.Source = source
+		newChecks[config.Base.Name] = true
+		err := config.Validate()
+		if err != nil {
+			return err
+		}
+		newCheck := NewFileHealthcheck(c.Logger, config)
+		err = c.AddCheck(newCheck)
+		if err != nil {
+			return errors.Wrapf(err, "Fail to add healthcheck %s", newCheck.Base().Name)
+		}
+	}
+	for i := range process {
+		config := &process[i]
+		MergeLabels(&config.Base, commonLabels)
+		config.Base.Source = source
+		newChecks[config.Base.Name] = true
+		err := config.Validate()
+		if err != nil {
+			return err
+		}
+		newCheck := NewProcessHealthcheck(c.Logger, config)
+		err = c.AddCheck(newCheck)
+		if err != nil {
+			return errors.Wrapf(err, "Fail to add healthcheck %s", newCheck.Base().Name)
+		}
+	}
+	for i := range host {
+		config := &host[i]
+		MergeLabels(&config.Base, commonLabels)
+		config.Base.Source = source
+		newChecks[config.Base.Name] = true
+		err := config.Validate()
+		if err != nil {
+			return err
+		}
+		newCheck := NewHostHealthcheck(c.Logger, config)
+		err = c.AddCheck(newCheck)
+		if err != nil {
+			return errors.Wrapf(err, "Fail to add healthcheck %s", newCheck.Base().Name)
+		}
+	}
+	for i := range prometheus {
+		config := &prometheus[i]
+		MergeLabels(&config.Base, commonLabels)
+		config.Base.Source = source
+		newChecks[config.Base.Name] = true
+		err := config.Validate()
+		if err != nil {
+			return err
+		}
+		newCheck := NewPrometheusHealthcheck(c.Logger, config)
+		err = c.AddCheck(newCheck)
+		if err != nil {
+			return errors.Wrapf(err, "Fail to add healthcheck %s", newCheck.Base().Name)
+		}
+	}
+	for i := range websocket {
+		config := &websocket[i]
+		MergeLabels(&config.Base, commonLabels)
+		config.Base.Source = source
+		newChecks[config.Base.Name] = true
+		err := config.Validate()
+		if err != nil {
+			return err
+		}
+		newCheck := NewWebSocketHealthcheck(c.Logger, config)
+		err = c.AddCheck(newCheck)
+		if err != nil {
+			return errors.Wrapf(err, "Fail to add healthcheck %s", newCheck.Base().Name)
+		}
+	}
+	for i := range mqtt {
+		config := &mqtt[i]
+		MergeLabels(&config.Base, commonLabels)
+		config.Base.Source = source
+		newChecks[config.Base.Name] = true
+		err := config.Validate()
+		if err != nil {
+			return err
+		}
+		newCheck := NewMQTTHealthcheck(c.Logger, config)
+		err = c.AddCheck(newCheck)
+		if err != nil {
+			return errors.Wrapf(err, "Fail to add healthcheck %s", newCheck.Base().Name)
+		}
+	}
+	for i := range ldap {
+		config := &ldap[i]
+		MergeLabels(&config.Base, commonLabels)
+		config.Base.Source = source
+		newChecks[config.Base.Name] = true
+		err := config.Validate()
+		if err != nil {
+			return err
+		}
+		newCheck := NewLDAPHealthcheck(c.Logger, config)
+		err = c.AddCheck(newCheck)
+		if err != nil {
+			return errors.Wrapf(err, "Fail to add healthcheck %s", newCheck.Base().Name)
+		}
+	}
+	for i := range ssh {
+		config := &ssh[i]
+		MergeLabels(&config.Base, commonLabels)
+		config.Base.Source = source
+		newChecks[config.Base.Name] = true
+		err := config.Validate()
+		if err != nil {
+			return err
+		}
+		newCheck := NewSSHHealthcheck(c.Logger, config)
+		err = c.AddCheck(newCheck)
+		if err != nil {
+			return errors.Wrapf(err, "Fail to add healthcheck %s", newCheck.Base().Name)
+		}
+	}
+	for i := range ntp {
+		config := &ntp[i]
+		MergeLabels(&config.Base, commonLabels)
+		config.Base.Source = source
+		newChecks[config.Base.Name] = true
+		err := config.Validate()
+		if err != nil {
+			return err
+		}
+		newCheck := NewNTPHealthcheck(c.Logger, config)
+		err = c.AddCheck(newCheck)
+		if err != nil {
+			return errors.Wrapf(err, "Fail to add healthcheck %s", newCheck.Base().Name)
+		}
+	}
+	for i := range snmp {
+		config := &snmp[i]
+		MergeLabels(&config.Base, commonLabels)
+		config.Base.Source = source
+		newChecks[config.Base.Name] = true
+		err := config.Validate()
+		if err != nil {
+			return err
+		}
+		newCheck := NewSNMPHealthcheck(c.Logger, config)
+		err = c.AddCheck(newCheck)
+		if err != nil {
+			return errors.Wrapf(err, "Fail to add healthcheck %s", newCheck.Base().Name)
+		}
+	}
+	for i := range docker {
+		config := &docker[i]
+		MergeLabels(&config.Base, commonLabels)
+		config.Base.Source = source
+		newChecks[config.Base.Name] = true
+		err := config.Validate()
+		if err != nil {
+			return err
+		}
+		newCheck := NewDockerHealthcheck(c.Logger, config)
+		err = c.AddCheck(newCheck)
+		if err != nil {
+			return errors.Wrapf(err, "Fail to add healthcheck %s", newCheck.Base().Name)
+		}
+	}
 	return c.RemoveNonConfiguredHealthchecks(oldChecks, newChecks)
 }
@@ -1,10 +1,21 @@
 package healthcheck
 
 import (
+	"context"
+	"fmt"
+	"math"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/pkg/errors"
 	"go.uber.org/zap"
+	"gopkg.in/tomb.v2"
 
 	"github.com/appclacks/cabourotte/prometheus"
 )
@@ -194,3 +205,882 @@ func TestMergeLabels(t *testing.T) {
 	}
 
 }
+
+func TestUpdateCheckGauges(t *testing.T) {
+	prom, err := prometheus.New()
+	if err != nil {
+		t.Fatalf("Error creating prometheus component :\n%v", err)
+	}
+	component, err := New(zap.NewExample(), make(chan *Result, 10), prom, []string{})
+	if err != nil {
+		t.Fatalf("Fail to create the component\n%v", err)
+	}
+	result := &Result{Details: map[string]interface{}{"offset": 0.25, "delay": 0.01}}
+	h := NewNTPHealthcheck(zap.NewExample(), &NTPHealthcheckConfiguration{})
+	component.updateCheckGauges(map[string]string{"name": "ntp"}, h.Gauges(result))
+	families, err := prom.Registry.Gather()
+	if err != nil {
+		t.Fatalf("Fail to gather the metrics\n%v", err)
+	}
+	found := 0
+	for _, family := range families {
+		if family.GetName() == "healthcheck_ntp_offset_seconds" && family.GetMetric()[0].GetGauge().GetValue() == 0.25 {
+			found++
+		}
+		if family.GetName() == "healthcheck_ntp_delay_seconds" && family.GetMetric()[0].GetGauge().GetValue() == 0.01 {
+			found++
+		}
+	}
+	if found != 2 {
+		t.Fatalf("The NTP gauges were not updated")
+	}
+	// the NTP server did not answer
+	result = &Result{}
+	component.updateCheckGauges(map[string]string{"name": "ntp"}, h.Gauges(result))
+	families, err = prom.Registry.Gather()
+	if err != nil {
+		t.Fatalf("Fail to gather the metrics\n%v", err)
+	}
+	found = 0
+	for _, family := range families {
+		if family.GetName() == "healthcheck_ntp_offset_seconds" || family.GetName() == "healthcheck_ntp_delay_seconds" {
+			if !math.IsNaN(family.GetMetric()[0].GetGauge().GetValue()) {
+				t.Fatalf("The %s gauge kept its previous value", family.GetName())
+			}
+			found++
+		}
+	}
+	if found != 2 {
+		t.Fatalf("The NTP gauges are missing")
+	}
+}
+
+func TestExecuteShouldFail(t *testing.T) {
+	h := NewCommandHealthcheck(
+		zap.NewExample(),
+		&CommandHealthcheckConfiguration{
+			Base: Base{
+				Name:       "foo",
+				ShouldFail: true,
+			},
+			Command: "false",
+			Timeout: Duration(time.Second * 2),
+		})
+	details, err := Execute(context.Background(), h)
+	if err != nil {
+		t.Fatalf("healthcheck error :\n%v", err)
+	}
+	if _, ok := details["expected-error"]; !ok {
+		t.Fatalf("Invalid details: %v", details)
+	}
+	result := NewResult(h, 0, err)
+	if result.Summary != "command false. This healthcheck has should-fail=true." {
+		t.Fatalf("Invalid summary: %s", result.Summary)
+	}
+	h.Config.Command = "true"
+	_, err = Execute(context.Background(), h)
+	if err == nil {
+		t.Fatalf("healthcheck was expected to fail")
+	}
+}
+
+func TestInvertIndefiniteFailures(t *testing.T) {
+	h := NewCommandHealthcheck(
+		zap.NewExample(),
+		&CommandHealthcheckConfiguration{
+			Base: Base{
+				Name:       "foo",
+				ShouldFail: true,
+			},
+			Command: "false",
+			Timeout: Duration(time.Second * 2),
+		})
+	cases := []error{
+		NewStatusError(StatusUnknown, errors.New("unknown target")),
+		errors.Wrap(context.Canceled, "Fail to connect"),
+		&abortedError{message: "The healthcheck was cancelled"},
+	}
+	for i, c := range cases {
+		_, err := invert(h, nil, c)
+		if err != c {
+			t.Fatalf("The error was inverted for case %d: %v", i, err)
+		}
+	}
+	for _, status := range []Status{StatusWarning, StatusCritical} {
+		_, err := invert(h, nil, NewStatusError(status, errors.New("failure")))
+		if err != nil {
+			t.Fatalf("The %s error was not inverted: %v", status, err)
+		}
+	}
+}
+
+func TestResultCheckDuration(t *testing.T) {
+	base := Base{
+		Name:             "foo",
+		WarningDuration:  Duration(time.Second),
+		CriticalDuration: Duration(time.Second * 5),
+	}
+	cases := []struct {
+		duration time.Duration
+		success  bool
+		status   Status
+	}{
+		{duration: time.Millisecond * 10, success: true, status: StatusOK},
+		{duration: time.Second * 2, success: true, status: StatusWarning},
+		{duration: time.Second * 8, success: false, status: StatusCritical},
+	}
+	for _, c := range cases {
+		result := &Result{Success: true, Status: StatusOK, Message: "success"}
+		result.checkDuration(base, c.duration)
+		if result.Success != c.success || result.Status != c.status {
+			t.Fatalf("Invalid result for duration %s: %v", c.duration.String(), result)
+		}
+	}
+	invalid := Base{
+		Name:             "foo",
+		WarningDuration:  Duration(time.Second * 5),
+		CriticalDuration: Duration(time.Second),
+	}
+	err := invalid.validate()
+	if err == nil {
+		t.Fatalf("Validation was expected to fail")
+	}
+}
+
+func TestErrorStatus(t *testing.T) {
+	cases := []struct {
+		err    error
+		status Status
+	}{
+		{err: nil, status: StatusOK},
+		{err: errors.New("failure"), status: StatusCritical},
+		{err: NewStatusError(StatusWarning, errors.New("slow")), status: StatusWarning},
+		{err: errors.Wrap(NewStatusError(StatusUnknown, errors.New("unsupported")), "context"), status: StatusUnknown},
+	}
+	for _, c := range cases {
+		status := ErrorStatus(c.err)
+		if status != c.status {
+			t.Fatalf("Invalid status for %v: %s", c.err, status)
+		}
+	}
+}
+
+func TestWrapperExecuteRetries(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "marker")
+	h := NewCommandHealthcheck(
+		zap.NewExample(),
+		&CommandHealthcheckConfiguration{
+			Base: Base{
+				Name:         "foo",
+				Retries:      2,
+				RetryDelay:   Duration(time.Millisecond * 10),
+				RetryBackoff: true,
+			},
+			Command: fmt.Sprintf("test -f %s || { touch %s; exit 1; }", marker, marker),
+			Shell:   true,
+			Timeout: Duration(time.Second * 2),
+		})
+	wrapper := NewWrapper(h)
+	result, _ := wrapper.execute(nil)
+	if !result.Success || result.Attempts != 2 {
+		t.Fatalf("Invalid result: %v", result)
+	}
+	h.Config.Command = "false"
+	result, _ = wrapper.execute(nil)
+	if result.Success || result.Attempts != 3 {
+		t.Fatalf("Invalid result: %v", result)
+	}
+	if h.Config.Base.retryDelay(3) != time.Millisecond*40 {
+		t.Fatalf("Invalid retry delay %s", h.Config.Base.retryDelay(3))
+	}
+	invalid := Base{
+		Name:       "foo",
+		Interval:   Duration(time.Second * 10),
+		Retries:    3,
+		RetryDelay: Duration(time.Second * 5),
+	}
+	err := invalid.validate()
+	if err == nil {
+		t.Fatalf("Validation was expected to fail")
+	}
+}
+
+func TestStateUpdate(t *testing.T) {
+	base := Base{
+		Name:          "foo",
+		Fall:          2,
+		Rise:          3,
+		FlapThreshold: 3,
+		FlapWindow:    Duration(time.Minute),
+	}
+	s := &state{}
+	now := time.Now()
+	cases := []struct {
+		raw      Status
+		status   Status
+		flapping bool
+	}{
+		{raw: StatusOK, status: StatusOK},
+		{raw: StatusCritical, status: StatusOK},
+		{raw: StatusCritical, status: StatusCritical},
+		{raw: StatusOK, status: StatusCritical},
+		{raw: StatusOK, status: StatusCritical},
+		{raw: StatusWarning, status: StatusWarning},
+		{raw: StatusCritical, status: StatusWarning, flapping: true},
+		{raw: StatusOK, status: StatusOK, flapping: true},
+	}
+	for i, c := range cases {
+		result := &Result{Status: c.raw, Success: c.raw.Success()}
+		s.update(base, result, now.Add(time.Duration(i)*time.Second))
+		if result.RawStatus != c.raw || result.Status != c.status || result.Success != c.status.Success() || result.Flapping != c.flapping {
+			t.Fatalf("Invalid result for case %d: %v", i, result)
+		}
+	}
+	result := &Result{Status: StatusOK, Success: true}
+	s.update(base, result, now.Add(time.Hour))
+	if result.Flapping {
+		t.Fatalf("The healthcheck should not be flapping anymore")
+	}
+}
+
+func TestWrapperUpdateInterval(t *testing.T) {
+	h := NewCommandHealthcheck(
+		zap.NewExample(),
+		&CommandHealthcheckConfiguration{
+			Base: Base{
+				Name:            "foo",
+				Interval:        Duration(time.Second * 30),
+				FailureInterval: Duration(time.Second * 5),
+				Rise:            2,
+			},
+			Command: "true",
+			Timeout: Duration(time.Second * 2),
+		})
+	wrapper := NewWrapper(h)
+	state := wrapper.RuntimeState()
+	if state.CurrentInterval == nil || *state.CurrentInterval != Duration(time.Second*30) || state.Status != "" {
+		t.Fatalf("Invalid state: %v", state)
+	}
+	cases := []struct {
+		status   Status
+		interval time.Duration
+	}{
+		{status: StatusOK, interval: time.Second * 30},
+		{status: StatusCritical, interval: time.Second * 5},
+		// the recovery is not confirmed yet
+		{status: StatusOK, interval: time.Second * 5},
+		{status: StatusOK, interval: time.Second * 30},
+	}
+	for i, c := range cases {
+		result := &Result{Status: c.status, Success: c.status.Success()}
+		interval := wrapper.update(result, time.Now())
+		if interval != c.interval {
+			t.Fatalf("Invalid interval for case %d: %s", i, interval.String())
+		}
+		state := wrapper.RuntimeState()
+		if *state.CurrentInterval != Duration(c.interval) || state.RawStatus != c.status {
+			t.Fatalf("Invalid state for case %d: %v", i, state)
+		}
+	}
+	wrapper.Timer = time.NewTimer(time.Hour)
+	wrapper.resetTimer(time.Millisecond*10, time.Now())
+	select {
+	case <-wrapper.Timer.C:
+	case <-time.After(time.Second):
+		t.Fatalf("The timer was not reset")
+	}
+}
+
+func TestValidateFailureInterval(t *testing.T) {
+	config := CommandHealthcheckConfiguration{
+		Base: Base{
+			Name:            "foo",
+			Interval:        Duration(time.Second * 30),
+			FailureInterval: Duration(time.Second * 5),
+		},
+		Command: "true",
+		Timeout: Duration(time.Second * 10),
+	}
+	err := config.Validate()
+	if err == nil {
+		t.Fatalf("Validation was expected to fail")
+	}
+	config.Timeout = Duration(time.Second * 5)
+	err = config.Validate()
+	if err != nil {
+		t.Fatalf("Validation error :\n%v", err)
+	}
+}
+
+func TestRemovedCheckResult(t *testing.T) {
+	logger := zap.NewExample()
+	prom, err := prometheus.New()
+	if err != nil {
+		t.Fatalf("Error creating prometheus component :\n%v", err)
+	}
+	chanResult := make(chan *Result, 10)
+	component, err := New(logger, chanResult, prom, []string{})
+	if err != nil {
+		t.Fatalf("Fail to create the component\n%v", err)
+	}
+	healthcheck := NewCommandHealthcheck(
+		logger,
+		&CommandHealthcheckConfiguration{
+			Base: Base{
+				Name:     "foo",
+				Schedule: "0 0 1 1 *",
+			},
+			Command: "true",
+			Timeout: Duration(time.Second * 2),
+		})
+	err = component.AddCheck(healthcheck)
+	if err != nil {
+		t.Fatalf("Fail to add the healthcheck\n%v", err)
+	}
+	wrapper := component.Healthchecks["foo"]
+	err = component.RemoveCheck("foo")
+	if err != nil {
+		t.Fatalf("Fail to remove the healthcheck\n%v", err)
+	}
+	// the execution ends after the removal
+	result := NewResult(healthcheck, 0, nil)
+	component.handleResult(wrapper, result, time.Millisecond)
+	if len(chanResult) != 0 {
+		t.Fatalf("The result of the removed healthcheck was sent")
+	}
+	families, err := prom.Registry.Gather()
+	if err != nil {
+		t.Fatalf("Fail to gather the metrics\n%v", err)
+	}
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "name" && label.GetValue() == "foo" {
+					t.Fatalf("The metric %s was recreated", family.GetName())
+				}
+			}
+		}
+	}
+	err = component.Stop()
+	if err != nil {
+		t.Fatalf("Fail to stop the component\n%v", err)
+	}
+}
+
+func TestPauseResumeCheck(t *testing.T) {
+	logger := zap.NewExample()
+	prom, err := prometheus.New()
+	if err != nil {
+		t.Fatalf("Error creating prometheus component :\n%v", err)
+	}
+	component, err := New(logger, make(chan *Result, 10), prom, []string{})
+	if err != nil {
+		t.Fatalf("Fail to create the component\n%v", err)
+	}
+	healthcheck := NewCommandHealthcheck(
+		logger,
+		&CommandHealthcheckConfiguration{
+			Base: Base{
+				Name:     "foo",
+				Interval: Duration(time.Second * 5),
+			},
+			Command: "true",
+			Timeout: Duration(time.Second * 2),
+		})
+	err = component.AddCheck(healthcheck)
+	if err != nil {
+		t.Fatalf("Fail to add the healthcheck\n%v", err)
+	}
+	err = component.PauseCheck("bar")
+	if err == nil {
+		t.Fatalf("Pausing an unknown healthcheck was expected to fail")
+	}
+	for i := 0; i < 2; i++ {
+		err = component.PauseCheck("foo")
+		if err != nil {
+			t.Fatalf("Fail to pause the healthcheck\n%v", err)
+		}
+	}
+	state := component.RuntimeStates()["foo"]
+	if !state.Paused || state.NextExecution != nil {
+		t.Fatalf("Invalid state: %v", state)
+	}
+	if component.GetCheck("foo") == nil {
+		t.Fatalf("The paused healthcheck should be kept")
+	}
+	for i := 0; i < 2; i++ {
+		err = component.ResumeCheck("foo")
+		if err != nil {
+			t.Fatalf("Fail to resume the healthcheck\n%v", err)
+		}
+	}
+	if component.RuntimeStates()["foo"].Paused {
+		t.Fatalf("The healthcheck should be resumed")
+	}
+	err = component.PauseCheck("foo")
+	if err != nil {
+		t.Fatalf("Fail to pause the healthcheck\n%v", err)
+	}
+	err = component.RemoveCheck("foo")
+	if err != nil {
+		t.Fatalf("Fail to remove the healthcheck\n%v", err)
+	}
+	err = component.Stop()
+	if err != nil {
+		t.Fatalf("Fail to stop the component\n%v", err)
+	}
+}
+
+func TestPausedCheckReplaced(t *testing.T) {
+	logger := zap.NewExample()
+	prom, err := prometheus.New()
+	if err != nil {
+		t.Fatalf("Error creating prometheus component :\n%v", err)
+	}
+	chanResult := make(chan *Result, 10)
+	component, err := New(logger, chanResult, prom, []string{})
+	if err != nil {
+		t.Fatalf("Fail to create the component\n%v", err)
+	}
+	newCheck := func(command string) *CommandHealthcheck {
+		return NewCommandHealthcheck(
+			logger,
+			&CommandHealthcheckConfiguration{
+				Base: Base{
+					Name:     "foo",
+					Interval: Duration(time.Second * 5),
+				},
+				Command: command,
+				Timeout: Duration(time.Second * 2),
+			})
+	}
+	err = component.AddCheck(newCheck("true"))
+	if err != nil {
+		t.Fatalf("Fail to add the healthcheck\n%v", err)
+	}
+	err = component.PauseCheck("foo")
+	if err != nil {
+		t.Fatalf("Fail to pause the healthcheck\n%v", err)
+	}
+	wrapper := component.Healthchecks["foo"]
+	wrapper.lock.RLock()
+	if wrapper.t != nil {
+		t.Fatalf("The paused healthcheck should not be scheduled")
+	}
+	wrapper.lock.RUnlock()
+	// the configuration is updated, like on a reload
+	err = component.AddCheck(newCheck("false"))
+	if err != nil {
+		t.Fatalf("Fail to replace the healthcheck\n%v", err)
+	}
+	wrapper = component.Healthchecks["foo"]
+	if !component.RuntimeStates()["foo"].Paused {
+		t.Fatalf("The replaced healthcheck should stay paused")
+	}
+	wrapper.lock.RLock()
+	if wrapper.t != nil {
+		t.Fatalf("The replaced healthcheck should not be scheduled")
+	}
+	wrapper.lock.RUnlock()
+	err = component.ResumeCheck("foo")
+	if err != nil {
+		t.Fatalf("Fail to resume the healthcheck\n%v", err)
+	}
+	select {
+	case result := <-chanResult:
+		if result.Success {
+			t.Fatalf("The new configuration was not executed: %v", result)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatalf("The resumed healthcheck was not executed")
+	}
+	err = component.Stop()
+	if err != nil {
+		t.Fatalf("Fail to stop the component\n%v", err)
+	}
+}
+
+func TestResumedCheckSuccess(t *testing.T) {
+	logger := zap.NewExample()
+	prom, err := prometheus.New()
+	if err != nil {
+		t.Fatalf("Error creating prometheus component :\n%v", err)
+	}
+	chanResult := make(chan *Result, 10)
+	component, err := New(logger, chanResult, prom, []string{})
+	if err != nil {
+		t.Fatalf("Fail to create the component\n%v", err)
+	}
+	healthcheck := NewCommandHealthcheck(
+		logger,
+		&CommandHealthcheckConfiguration{
+			Base: Base{
+				Name:     "foo",
+				Schedule: "0 0 1 1 *",
+			},
+			Command: "true",
+			Timeout: Duration(time.Second * 2),
+		})
+	err = component.AddCheck(healthcheck)
+	if err != nil {
+		t.Fatalf("Fail to add the healthcheck\n%v", err)
+	}
+	err = component.PauseCheck("foo")
+	if err != nil {
+		t.Fatalf("Fail to pause the healthcheck\n%v", err)
+	}
+	result, err := component.RunCheck("foo")
+	if err != nil {
+		t.Fatalf("Fail to run the healthcheck\n%v", err)
+	}
+	if !result.Success {
+		t.Fatalf("Invalid result for the paused healthcheck: %v", result)
+	}
+	<-chanResult
+	err = component.ResumeCheck("foo")
+	if err != nil {
+		t.Fatalf("Fail to resume the healthcheck\n%v", err)
+	}
+	// the resumed healthcheck is executed immediately
+	select {
+	case result = <-chanResult:
+	case <-time.After(time.Second * 5):
+		t.Fatalf("The resumed healthcheck was not executed")
+	}
+	if !result.Success {
+		t.Fatalf("Invalid result for the resumed healthcheck: %v", result)
+	}
+	err = component.Stop()
+	if err != nil {
+		t.Fatalf("Fail to stop the component\n%v", err)
+	}
+}
+
+// closeCountingHealthcheck counts the calls to Close
+type closeCountingHealthcheck struct {
+	*CommandHealthcheck
+	closed int32
+}
+
+func (h *closeCountingHealthcheck) Close() error {
+	atomic.AddInt32(&h.closed, 1)
+	return nil
+}
+
+func TestPauseResumeRunCheckConcurrently(t *testing.T) {
+	logger := zap.NewExample()
+	prom, err := prometheus.New()
+	if err != nil {
+		t.Fatalf("Error creating prometheus component :\n%v", err)
+	}
+	chanResult := make(chan *Result, 100)
+	component, err := New(logger, chanResult, prom, []string{})
+	if err != nil {
+		t.Fatalf("Fail to create the component\n%v", err)
+	}
+	healthcheck := &closeCountingHealthcheck{
+		CommandHealthcheck: NewCommandHealthcheck(
+			logger,
+			&CommandHealthcheckConfiguration{
+				Base: Base{
+					Name:     "foo",
+					Interval: Duration(time.Second * 5),
+				},
+				Command: "true",
+				Timeout: Duration(time.Second * 2),
+			}),
+	}
+	err = component.AddCheck(healthcheck)
+	if err != nil {
+		t.Fatalf("Fail to add the healthcheck\n%v", err)
+	}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			result, err := component.RunCheck("foo")
+			if err != nil || !result.Success {
+				t.Errorf("Invalid result: %v %v", result, err)
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			err := component.PauseCheck("foo")
+			if err != nil {
+				t.Errorf("Fail to pause the healthcheck\n%v", err)
+				return
+			}
+			err = component.ResumeCheck("foo")
+			if err != nil {
+				t.Errorf("Fail to resume the healthcheck\n%v", err)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+	if atomic.LoadInt32(&healthcheck.closed) != 0 {
+		t.Fatalf("Pausing the healthcheck should not close it")
+	}
+	err = component.Stop()
+	if err != nil {
+		t.Fatalf("Fail to stop the component\n%v", err)
+	}
+	if atomic.LoadInt32(&healthcheck.closed) != 1 {
+		t.Fatalf("Stopping the healthcheck should close it")
+	}
+}
+
+func TestRunCheck(t *testing.T) {
+	logger := zap.NewExample()
+	prom, err := prometheus.New()
+	if err != nil {
+		t.Fatalf("Error creating prometheus component :\n%v", err)
+	}
+	chanResult := make(chan *Result, 10)
+	component, err := New(logger, chanResult, prom, []string{})
+	if err != nil {
+		t.Fatalf("Fail to create the component\n%v", err)
+	}
+	checks := []struct {
+		name    string
+		command string
+		env     string
+	}{
+		{name: "foo", command: "true", env: "prod"},
+		{name: "bar", command: "false", env: "prod"},
+		{name: "baz", command: "true", env: "dev"},
+	}
+	for _, c := range checks {
+		err = component.AddCheck(NewCommandHealthcheck(
+			logger,
+			&CommandHealthcheckConfiguration{
+				Base: Base{
+					Name:     c.name,
+					Schedule: "0 0 1 1 *",
+					Labels:   map[string]string{"env": c.env},
+				},
+				Command: c.command,
+				Timeout: Duration(time.Second * 2),
+			}))
+		if err != nil {
+			t.Fatalf("Fail to add the healthcheck\n%v", err)
+		}
+	}
+	var next *time.Time
+	for i := 0; i < 100 && next == nil; i++ {
+		next = component.RuntimeStates()["foo"].NextExecution
+		time.Sleep(10 * time.Millisecond)
+	}
+	if next == nil {
+		t.Fatalf("The healthcheck next execution is not scheduled")
+	}
+	_, err = component.RunCheck("unknown")
+	if err == nil {
+		t.Fatalf("Running an unknown healthcheck was expected to fail")
+	}
+	result, err := component.RunCheck("foo")
+	if err != nil {
+		t.Fatalf("Fail to run the healthcheck\n%v", err)
+	}
+	if result.Name != "foo" || !result.Success {
+		t.Fatalf("Invalid result: %v", result)
+	}
+	sent := <-chanResult
+	if sent != result {
+		t.Fatalf("The result was not sent to the result channel")
+	}
+	state := component.RuntimeStates()["foo"]
+	if state.Status != StatusOK || state.NextExecution == nil || !state.NextExecution.Equal(*next) {
+		t.Fatalf("Invalid state: %v", state)
+	}
+	results := component.RunChecks(map[string]string{"env": "prod"})
+	if len(results) != 2 || results[0].Name != "bar" || results[0].Success || results[1].Name != "foo" {
+		t.Fatalf("Invalid results: %v", results)
+	}
+	if len(chanResult) != 2 {
+		t.Fatalf("The results were not sent to the result channel")
+	}
+	families, err := prom.Registry.Gather()
+	if err != nil {
+		t.Fatalf("Fail to gather the metrics\n%v", err)
+	}
+	expected := map[string]bool{
+		"foo/success/ok":       false,
+		"bar/failure/critical": false,
+	}
+	for _, family := range families {
+		if family.GetName() != "healthcheck_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			names := []string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+				names = append(names, label.GetName())
+			}
+			sort.Strings(names)
+			if strings.Join(names, ",") != "name,severity,status" {
+				t.Fatalf("Invalid healthcheck_total labels: %v", names)
+			}
+			key := fmt.Sprintf("%s/%s/%s", labels["name"], labels["status"], labels["severity"])
+			if _, ok := expected[key]; ok {
+				expected[key] = true
+			}
+		}
+	}
+	for key, found := range expected {
+		if !found {
+			t.Fatalf("The healthcheck_total metric %s is missing", key)
+		}
+	}
+	err = component.Stop()
+	if err != nil {
+		t.Fatalf("Fail to stop the component\n%v", err)
+	}
+}
+
+// blockingHealthcheck is an healthcheck ignoring its context
+type blockingHealthcheck struct {
+	*CommandHealthcheck
+	release chan struct{}
+}
+
+func (h *blockingHealthcheck) Execute(ctx context.Context) error {
+	<-h.release
+	return nil
+}
+
+func (h *blockingHealthcheck) ExecuteWithDetails(ctx context.Context) (map[string]interface{}, error) {
+	return nil, h.Execute(ctx)
+}
+
+func TestWrapperExecuteTimeout(t *testing.T) {
+	h := &blockingHealthcheck{
+		CommandHealthcheck: NewCommandHealthcheck(
+			zap.NewExample(),
+			&CommandHealthcheckConfiguration{
+				Base: Base{
+					Name:     "foo",
+					Interval: Duration(time.Second * 10),
+				},
+				Command: "true",
+				Timeout: Duration(time.Millisecond * 100),
+			}),
+		release: make(chan struct{}),
+	}
+	defer close(h.release)
+	wrapper := NewWrapper(h)
+	start := time.Now()
+	result, _ := wrapper.execute(nil)
+	if time.Since(start) > time.Second*2 {
+		t.Fatalf("The wrapper did not enforce the timeout")
+	}
+	if result.Success || result.Status != StatusCritical || result.Message != "The healthcheck timed out after 100ms" {
+		t.Fatalf("Invalid result: %v", result)
+	}
+	// the timeout is not inverted for healthchecks expected to fail
+	h.Config.Base.ShouldFail = true
+	result, _ = wrapper.execute(nil)
+	if result.Success || result.Message != "The healthcheck timed out after 100ms" {
+		t.Fatalf("Invalid result: %v", result)
+	}
+}
+
+func TestWrapperStopCancel(t *testing.T) {
+	h := &blockingHealthcheck{
+		CommandHealthcheck: NewCommandHealthcheck(
+			zap.NewExample(),
+			&CommandHealthcheckConfiguration{
+				Base: Base{
+					Name:     "foo",
+					Interval: Duration(time.Second * 10),
+					Retries:  2,
+				},
+				Command: "true",
+				Timeout: Duration(time.Second * 5),
+			}),
+		release: make(chan struct{}),
+	}
+	defer close(h.release)
+	wrapper := NewWrapper(h)
+	wrapper.Timer = time.NewTimer(time.Hour)
+	results := make(chan *Result, 1)
+	loop := new(tomb.Tomb)
+	wrapper.t = loop
+	loop.Go(func() error {
+		result, _ := wrapper.execute(loop.Dying())
+		results <- result
+		return nil
+	})
+	time.Sleep(100 * time.Millisecond)
+	start := time.Now()
+	err := wrapper.Stop()
+	if err != nil {
+		t.Fatalf("Fail to stop the wrapper\n%v", err)
+	}
+	result := <-results
+	if time.Since(start) > time.Second*2 {
+		t.Fatalf("The execution was not cancelled")
+	}
+	if result.Success || result.Message != "The healthcheck was cancelled" || result.Attempts != 1 {
+		t.Fatalf("Invalid result: %v", result)
+	}
+}
+
+func TestWrapperExecuteContext(t *testing.T) {
+	h := NewCommandHealthcheck(
+		zap.NewExample(),
+		&CommandHealthcheckConfiguration{
+			Base: Base{
+				Name:     "foo",
+				Interval: Duration(time.Second * 10),
+			},
+			Command:   "sleep",
+			Arguments: []string{"10"},
+			Timeout:   Duration(time.Millisecond * 200),
+		})
+	wrapper := NewWrapper(h)
+	start := time.Now()
+	result, _ := wrapper.execute(nil)
+	if time.Since(start) > time.Second*2 {
+		t.Fatalf("The healthcheck did not honor its context")
+	}
+	if result.Success {
+		t.Fatalf("Invalid result: %v", result)
+	}
+}
+
+func TestWrapperExecuteCommandTimeoutDetails(t *testing.T) {
+	if _, err := exec.LookPath("setsid"); err != nil {
+		t.Skip("setsid is not available")
+	}
+	h := NewCommandHealthcheck(
+		zap.NewExample(),
+		&CommandHealthcheckConfiguration{
+			Base: Base{
+				Name:     "foo",
+				Interval: Duration(time.Second * 10),
+			},
+			// the process outside of the group keeps the outputs open
+			Command: "echo partial; setsid sleep 2 & sleep 10",
+			Shell:   true,
+			Timeout: Duration(time.Millisecond * 200),
+		})
+	err := h.Initialize()
+	if err != nil {
+		t.Fatalf("Fail to initialize the healthcheck :\n%v", err)
+	}
+	wrapper := NewWrapper(h)
+	result, _ := wrapper.execute(nil)
+	if result.Success {
+		t.Fatalf("Invalid result: %v", result)
+	}
+	if result.Details["termination"] != "timeout" || result.Details["stdout"] != "partial\n" {
+		t.Fatalf("The command details were not reported: %v", result.Details)
+	}
+}
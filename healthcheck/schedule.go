@@ -0,0 +1,205 @@
+package healthcheck
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ActiveWindow defines a time window during which an healthcheck is
+// executed. A window ending before its start ends the next day, the days
+// are the days on which the window starts.
+type ActiveWindow struct {
+	// mon, tue, wed, thu, fri, sat or sun. All days if empty.
+	Days []string `json:"days,omitempty"`
+	// 15:04 format
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// parseClock returns the number of minutes since midnight of a 15:04 time
+func parseClock(clock string) (int, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, errors.Wrapf(err, "Invalid time %s", clock)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// validate validates the active window
+func (w *ActiveWindow) validate() error {
+	for _, day := range w.Days {
+		if _, ok := weekdays[day]; !ok {
+			return fmt.Errorf("Invalid active window day %s", day)
+		}
+	}
+	start, err := parseClock(w.Start)
+	if err != nil {
+		return err
+	}
+	end, err := parseClock(w.End)
+	if err != nil {
+		return err
+	}
+	if start == end {
+		return errors.New("The active window start and end should be different")
+	}
+	return nil
+}
+
+// hasDay returns true if the window starts on the given day
+func (w *ActiveWindow) hasDay(day time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, d := range w.Days {
+		if weekdays[d] == day {
+			return true
+		}
+	}
+	return false
+}
+
+// contains returns true if the time is within the window
+func (w *ActiveWindow) contains(t time.Time) bool {
+	start, err := parseClock(w.Start)
+	if err != nil {
+		return false
+	}
+	end, err := parseClock(w.End)
+	if err != nil {
+		return false
+	}
+	minutes := t.Hour()*60 + t.Minute()
+	if start < end {
+		return w.hasDay(t.Weekday()) && minutes >= start && minutes < end
+	}
+	// the window ends the next day
+	if minutes >= start {
+		return w.hasDay(t.Weekday())
+	}
+	return minutes < end && w.hasDay(t.AddDate(0, 0, -1).Weekday())
+}
+
+// location returns the time zone of the schedule and of the active windows
+func (b *Base) location() (*time.Location, error) {
+	if b.TimeZone == "" {
+		return time.Local, nil
+	}
+	location, err := time.LoadLocation(b.TimeZone)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Invalid time zone %s", b.TimeZone)
+	}
+	return location, nil
+}
+
+// cronSchedule parses the healthcheck schedule, returning nil if the
+// healthcheck is executed periodically using its interval
+func (b *Base) cronSchedule() (cron.Schedule, error) {
+	if b.Schedule == "" {
+		return nil, nil
+	}
+	spec := b.Schedule
+	if b.TimeZone != "" {
+		spec = fmt.Sprintf("CRON_TZ=%s %s", b.TimeZone, b.Schedule)
+	}
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Invalid schedule %s", b.Schedule)
+	}
+	return schedule, nil
+}
+
+// scheduleSamples is the number of scheduled executions checked to find the
+// minimum delay between two executions
+const scheduleSamples = 1000
+
+// minScheduleGap returns the minimum delay between two consecutive
+// executions of a schedule, or 0 if it is executed only once
+func minScheduleGap(schedule cron.Schedule, from time.Time) time.Duration {
+	gap := time.Duration(0)
+	previous := schedule.Next(from)
+	for i := 0; i < scheduleSamples && !previous.IsZero(); i++ {
+		next := schedule.Next(previous)
+		if next.IsZero() {
+			break
+		}
+		if delay := next.Sub(previous); gap == 0 || delay < gap {
+			gap = delay
+		}
+		previous = next
+	}
+	return gap
+}
+
+// active returns true if the healthcheck should be executed at the given time
+func (b *Base) active(t time.Time) bool {
+	if len(b.ActiveWindows) == 0 {
+		return true
+	}
+	location, err := b.location()
+	if err != nil {
+		return true
+	}
+	t = t.In(location)
+	for i := range b.ActiveWindows {
+		if b.ActiveWindows[i].contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateSchedule validates the schedule, the time zone and the active
+// windows
+func (b *Base) validateSchedule() error {
+	if b.Schedule != "" {
+		if b.Interval != 0 {
+			return errors.New("The healthcheck interval and schedule are mutually exclusive")
+		}
+		if b.OneOff {
+			return errors.New("The healthcheck schedule is not supported for one-off healthchecks")
+		}
+		if strings.Contains(b.Schedule, "TZ=") {
+			return errors.New("The healthcheck schedule time zone should be set using the time-zone option")
+		}
+	}
+	_, err := b.location()
+	if err != nil {
+		return err
+	}
+	_, err = b.cronSchedule()
+	if err != nil {
+		return err
+	}
+	for i := range b.ActiveWindows {
+		err := b.ActiveWindows[i].validate()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ActiveWindow) DeepCopyInto(out *ActiveWindow) {
+	*out = *in
+	if in.Days != nil {
+		in, out := &in.Days, &out.Days
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
@@ -0,0 +1,195 @@
+package healthcheck
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestActiveWindowContains(t *testing.T) {
+	// 2024-01-01 is a monday
+	cases := []struct {
+		window   ActiveWindow
+		time     time.Time
+		expected bool
+	}{
+		{
+			window:   ActiveWindow{Start: "08:00", End: "18:00"},
+			time:     time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC),
+			expected: true,
+		},
+		{
+			window:   ActiveWindow{Start: "08:00", End: "18:00"},
+			time:     time.Date(2024, 1, 1, 18, 0, 0, 0, time.UTC),
+			expected: false,
+		},
+		{
+			window:   ActiveWindow{Days: []string{"tue"}, Start: "08:00", End: "18:00"},
+			time:     time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+			expected: false,
+		},
+		{
+			window:   ActiveWindow{Days: []string{"mon"}, Start: "22:00", End: "02:00"},
+			time:     time.Date(2024, 1, 1, 23, 0, 0, 0, time.UTC),
+			expected: true,
+		},
+		{
+			window:   ActiveWindow{Days: []string{"mon"}, Start: "22:00", End: "02:00"},
+			time:     time.Date(2024, 1, 2, 1, 0, 0, 0, time.UTC),
+			expected: true,
+		},
+		{
+			window:   ActiveWindow{Days: []string{"mon"}, Start: "22:00", End: "02:00"},
+			time:     time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC),
+			expected: false,
+		},
+	}
+	for i, c := range cases {
+		if c.window.contains(c.time) != c.expected {
+			t.Fatalf("Invalid result for case %d, expected %t", i, c.expected)
+		}
+	}
+}
+
+func TestBaseActive(t *testing.T) {
+	base := Base{
+		TimeZone: "Europe/Paris",
+		ActiveWindows: []ActiveWindow{
+			{Start: "08:00", End: "09:00"},
+		},
+	}
+	if !base.active(time.Date(2024, 1, 1, 7, 30, 0, 0, time.UTC)) {
+		t.Fatalf("The healthcheck should be active")
+	}
+	if base.active(time.Date(2024, 1, 1, 8, 30, 0, 0, time.UTC)) {
+		t.Fatalf("The healthcheck should not be active")
+	}
+	base.ActiveWindows = nil
+	if !base.active(time.Date(2024, 1, 1, 8, 30, 0, 0, time.UTC)) {
+		t.Fatalf("The healthcheck should be active")
+	}
+}
+
+func TestCronSchedule(t *testing.T) {
+	base := Base{
+		Schedule: "30 8 * * *",
+		TimeZone: "Europe/Paris",
+	}
+	schedule, err := base.cronSchedule()
+	if err != nil {
+		t.Fatalf("Fail to parse the schedule :\n%v", err)
+	}
+	next := schedule.Next(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	expected := time.Date(2024, 1, 1, 7, 30, 0, 0, time.UTC)
+	if !next.Equal(expected) {
+		t.Fatalf("Invalid next execution\nexpected: %s\nactual: %s", expected, next)
+	}
+	base.Schedule = ""
+	schedule, err = base.cronSchedule()
+	if err != nil || schedule != nil {
+		t.Fatalf("No schedule was expected")
+	}
+}
+
+func TestValidateSchedule(t *testing.T) {
+	cases := []Base{
+		{Name: "foo", Schedule: "* * * * *", Interval: Duration(time.Second * 10)},
+		{Name: "foo", Schedule: "* * * * *", OneOff: true},
+		{Name: "foo", Schedule: "CRON_TZ=UTC * * * * *"},
+		{Name: "foo", Schedule: "* * *"},
+		{Name: "foo", Schedule: "* * * * *", TimeZone: "Foo/Bar"},
+		{Name: "foo", Interval: Duration(time.Second * 10), ActiveWindows: []ActiveWindow{{Start: "25:00", End: "10:00"}}},
+		{Name: "foo", Interval: Duration(time.Second * 10), ActiveWindows: []ActiveWindow{{Start: "10:00", End: "10:00"}}},
+		{Name: "foo", Interval: Duration(time.Second * 10), ActiveWindows: []ActiveWindow{{Days: []string{"monday"}, Start: "08:00", End: "10:00"}}},
+	}
+	for i := range cases {
+		err := cases[i].validate()
+		if err == nil {
+			t.Fatalf("Validation was expected to fail for case %d", i)
+		}
+	}
+	config := CommandHealthcheckConfiguration{
+		Base: Base{
+			Name:     "foo",
+			Schedule: "*/5 * * * *",
+			TimeZone: "UTC",
+			ActiveWindows: []ActiveWindow{
+				{Days: []string{"mon", "fri"}, Start: "22:00", End: "02:00"},
+			},
+		},
+		Command: "true",
+		Timeout: Duration(time.Second * 2),
+	}
+	err := config.Validate()
+	if err != nil {
+		t.Fatalf("Validation error :\n%v", err)
+	}
+	// executed at 00:00 and 00:01
+	config.Base.Schedule = "0,1 0 * * *"
+	config.Timeout = Duration(time.Minute * 2)
+	err = config.Validate()
+	if err == nil {
+		t.Fatalf("Validation was expected to fail")
+	}
+	config.Timeout = Duration(time.Minute)
+	err = config.Validate()
+	if err != nil {
+		t.Fatalf("Validation error :\n%v", err)
+	}
+}
+
+func TestMinScheduleGap(t *testing.T) {
+	cases := []struct {
+		schedule string
+		gap      time.Duration
+	}{
+		{schedule: "*/5 * * * *", gap: time.Minute * 5},
+		{schedule: "0,1 0 * * *", gap: time.Minute},
+		{schedule: "0 0 * * mon,tue", gap: time.Hour * 24},
+		{schedule: "0 0 29 2 *", gap: time.Hour * 24 * 1461},
+	}
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	for _, c := range cases {
+		base := Base{Schedule: c.schedule, TimeZone: "UTC"}
+		schedule, err := base.cronSchedule()
+		if err != nil {
+			t.Fatalf("Invalid schedule %s :\n%v", c.schedule, err)
+		}
+		gap := minScheduleGap(schedule, from)
+		if gap != c.gap {
+			t.Fatalf("Invalid gap for %s: %s", c.schedule, gap.String())
+		}
+	}
+}
+
+func TestWrapperResetTimerSchedule(t *testing.T) {
+	h := NewCommandHealthcheck(
+		zap.NewExample(),
+		&CommandHealthcheckConfiguration{
+			Base: Base{
+				Name:     "foo",
+				Schedule: "* * * * *",
+			},
+			Command: "true",
+			Timeout: Duration(time.Second * 2),
+		})
+	base := h.Base()
+	schedule, err := base.cronSchedule()
+	if err != nil {
+		t.Fatalf("Fail to parse the schedule :\n%v", err)
+	}
+	wrapper := NewWrapper(h)
+	wrapper.schedule = schedule
+	wrapper.Timer = time.NewTimer(time.Hour)
+	now := time.Now()
+	wrapper.resetTimer(wrapper.currentInterval(), now)
+	state := wrapper.RuntimeState()
+	if state.CurrentInterval != nil || state.NextExecution == nil {
+		t.Fatalf("Invalid state: %v", state)
+	}
+	if state.NextExecution.Second() != 0 || state.NextExecution.Sub(now) > time.Minute {
+		t.Fatalf("Invalid next execution: %s", state.NextExecution)
+	}
+	wrapper.Timer.Stop()
+}
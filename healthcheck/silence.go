@@ -0,0 +1,205 @@
+package healthcheck
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// silenceMatches returns true if the healthcheck is selected by the silence
+// matchers. The healthcheck should have one of the names if names are set,
+// and all the labels if labels are set.
+func silenceMatches(names []string, matchers map[string]string, name string, labels map[string]string) bool {
+	if len(names) != 0 {
+		found := false
+		for _, n := range names {
+			if n == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return matchLabels(matchers, labels)
+}
+
+// Silence mutes the results of the matching healthchecks between its start
+// and its end
+type Silence struct {
+	ID      string            `json:"id"`
+	Names   []string          `json:"names,omitempty"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Start   time.Time         `json:"start"`
+	End     time.Time         `json:"end"`
+	Comment string            `json:"comment,omitempty"`
+}
+
+// Validate validates the silence
+func (s *Silence) Validate() error {
+	if len(s.Names) == 0 && len(s.Labels) == 0 {
+		return errors.New("The silence names or labels are missing")
+	}
+	if s.End.IsZero() {
+		return errors.New("The silence end is missing")
+	}
+	if !s.Start.IsZero() && !s.End.After(s.Start) {
+		return errors.New("The silence end should be after its start")
+	}
+	return nil
+}
+
+// RecurringSilence mutes the results of the matching healthchecks during its
+// windows. Recurring silences are defined in the configuration file.
+type RecurringSilence struct {
+	Names   []string          `json:"names,omitempty"`
+	Labels  map[string]string `json:"labels,omitempty"`
+	Windows []ActiveWindow    `json:"windows"`
+	// time zone of the windows, local time by default
+	TimeZone string `json:"time-zone,omitempty" yaml:"time-zone,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// Validate validates the recurring silence
+func (s *RecurringSilence) Validate() error {
+	if len(s.Names) == 0 && len(s.Labels) == 0 {
+		return errors.New("The silence names or labels are missing")
+	}
+	if len(s.Windows) == 0 {
+		return errors.New("The silence windows are missing")
+	}
+	base := Base{TimeZone: s.TimeZone}
+	if _, err := base.location(); err != nil {
+		return err
+	}
+	for i := range s.Windows {
+		err := s.Windows[i].validate()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// active returns true if the time is within one of the silence windows
+func (s *RecurringSilence) active(t time.Time) bool {
+	base := Base{
+		TimeZone:      s.TimeZone,
+		ActiveWindows: s.Windows,
+	}
+	return base.active(t)
+}
+
+// Silences stores the silences in memory
+type Silences struct {
+	lock      sync.RWMutex
+	silences  map[string]*Silence
+	recurring []RecurringSilence
+}
+
+// NewSilences creates an empty silences store
+func NewSilences() *Silences {
+	return &Silences{
+		silences: make(map[string]*Silence),
+	}
+}
+
+// purge removes the expired silences. The lock should be acquired by the
+// caller.
+func (s *Silences) purge(now time.Time) {
+	for id, silence := range s.silences {
+		if !silence.End.After(now) {
+			delete(s.silences, id)
+		}
+	}
+}
+
+// Add adds a silence, returning it with its ID
+func (s *Silences) Add(silence Silence) (*Silence, error) {
+	err := silence.Validate()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if !silence.End.After(now) {
+		return nil, errors.New("The silence is already expired")
+	}
+	if silence.Start.IsZero() {
+		silence.Start = now
+	}
+	silence.ID = uuid.New().String()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.purge(now)
+	s.silences[silence.ID] = &silence
+	return &silence, nil
+}
+
+// Delete deletes a silence
+func (s *Silences) Delete(id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, ok := s.silences[id]; !ok {
+		return fmt.Errorf("Silence %s not found", id)
+	}
+	delete(s.silences, id)
+	return nil
+}
+
+// List returns the silences which are not expired, sorted by start
+func (s *Silences) List() []Silence {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.purge(time.Now())
+	result := make([]Silence, 0, len(s.silences))
+	for _, silence := range s.silences {
+		result = append(result, *silence)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Start.Equal(result[j].Start) {
+			return result[i].ID < result[j].ID
+		}
+		return result[i].Start.Before(result[j].Start)
+	})
+	return result
+}
+
+// SetRecurring replaces the recurring silences
+func (s *Silences) SetRecurring(recurring []RecurringSilence) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.recurring = recurring
+}
+
+// Recurring returns the recurring silences
+func (s *Silences) Recurring() []RecurringSilence {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	result := make([]RecurringSilence, len(s.recurring))
+	copy(result, s.recurring)
+	return result
+}
+
+// Silenced returns true if the healthcheck is muted by a silence at the
+// given time
+func (s *Silences) Silenced(name string, labels map[string]string, t time.Time) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	for _, silence := range s.silences {
+		if !t.Before(silence.Start) && t.Before(silence.End) && silenceMatches(silence.Names, silence.Labels, name, labels) {
+			return true
+		}
+	}
+	for i := range s.recurring {
+		silence := s.recurring[i]
+		if silenceMatches(silence.Names, silence.Labels, name, labels) && silence.active(t) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package healthcheck
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSilenceMatches(t *testing.T) {
+	labels := map[string]string{"env": "prod", "team": "infra"}
+	cases := []struct {
+		names    []string
+		matchers map[string]string
+		expected bool
+	}{
+		{names: []string{"foo"}, expected: true},
+		{names: []string{"bar", "foo"}, expected: true},
+		{names: []string{"bar"}, expected: false},
+		{matchers: map[string]string{"env": "prod"}, expected: true},
+		{matchers: map[string]string{"env": "prod", "team": "db"}, expected: false},
+		{matchers: map[string]string{"region": "eu"}, expected: false},
+		{names: []string{"foo"}, matchers: map[string]string{"env": "staging"}, expected: false},
+	}
+	for i, c := range cases {
+		if silenceMatches(c.names, c.matchers, "foo", labels) != c.expected {
+			t.Fatalf("Invalid result for case %d, expected %t", i, c.expected)
+		}
+	}
+}
+
+func TestSilencesAddDelete(t *testing.T) {
+	silences := NewSilences()
+	now := time.Now()
+	invalid := []Silence{
+		{End: now.Add(time.Hour)},
+		{Names: []string{"foo"}},
+		{Names: []string{"foo"}, Start: now.Add(time.Hour), End: now},
+		{Names: []string{"foo"}, Start: now.Add(-2 * time.Hour), End: now.Add(-time.Hour)},
+	}
+	for i := range invalid {
+		_, err := silences.Add(invalid[i])
+		if err == nil {
+			t.Fatalf("Adding the silence was expected to fail for case %d", i)
+		}
+	}
+	silence, err := silences.Add(Silence{
+		Names: []string{"foo"},
+		Start: now.Add(time.Hour),
+		End:   now.Add(2 * time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("Fail to add the silence :\n%v", err)
+	}
+	if silence.ID == "" {
+		t.Fatalf("The silence ID is missing")
+	}
+	if silences.Silenced("foo", nil, now) {
+		t.Fatalf("The silence is not started yet")
+	}
+	if !silences.Silenced("foo", nil, now.Add(90*time.Minute)) {
+		t.Fatalf("The healthcheck should be silenced")
+	}
+	if silences.Silenced("bar", nil, now.Add(90*time.Minute)) {
+		t.Fatalf("The healthcheck should not be silenced")
+	}
+	list := silences.List()
+	if len(list) != 1 || list[0].ID != silence.ID {
+		t.Fatalf("Invalid silences: %v", list)
+	}
+	err = silences.Delete(silence.ID)
+	if err != nil {
+		t.Fatalf("Fail to delete the silence :\n%v", err)
+	}
+	err = silences.Delete(silence.ID)
+	if err == nil {
+		t.Fatalf("Deleting an unknown silence was expected to fail")
+	}
+	if len(silences.List()) != 0 {
+		t.Fatalf("The silence was not deleted")
+	}
+}
+
+func TestSilencesRecurring(t *testing.T) {
+	invalid := []RecurringSilence{
+		{Windows: []ActiveWindow{{Start: "02:00", End: "04:00"}}},
+		{Names: []string{"foo"}},
+		{Names: []string{"foo"}, Windows: []ActiveWindow{{Start: "02:00", End: "04:00"}}, TimeZone: "Foo/Bar"},
+		{Names: []string{"foo"}, Windows: []ActiveWindow{{Start: "02:00", End: "02:00"}}},
+	}
+	for i := range invalid {
+		if invalid[i].Validate() == nil {
+			t.Fatalf("Validation was expected to fail for case %d", i)
+		}
+	}
+	recurring := RecurringSilence{
+		Labels:   map[string]string{"env": "prod"},
+		TimeZone: "UTC",
+		Windows: []ActiveWindow{
+			{Days: []string{"sun"}, Start: "02:00", End: "04:00"},
+		},
+	}
+	err := recurring.Validate()
+	if err != nil {
+		t.Fatalf("Validation error :\n%v", err)
+	}
+	silences := NewSilences()
+	silences.SetRecurring([]RecurringSilence{recurring})
+	labels := map[string]string{"env": "prod"}
+	// 2024-01-07 is a sunday
+	if !silences.Silenced("foo", labels, time.Date(2024, 1, 7, 3, 0, 0, 0, time.UTC)) {
+		t.Fatalf("The healthcheck should be silenced")
+	}
+	if silences.Silenced("foo", labels, time.Date(2024, 1, 8, 3, 0, 0, 0, time.UTC)) {
+		t.Fatalf("The healthcheck should not be silenced")
+	}
+	if silences.Silenced("foo", nil, time.Date(2024, 1, 7, 3, 0, 0, 0, time.UTC)) {
+		t.Fatalf("The healthcheck should not be silenced")
+	}
+	if len(silences.Recurring()) != 1 {
+		t.Fatalf("Invalid recurring silences")
+	}
+}
@@ -0,0 +1,414 @@
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+const (
+	// SNMPVersion2c the SNMP v2c protocol version
+	SNMPVersion2c = "2c"
+	// SNMPVersion3 the SNMP v3 protocol version
+	SNMPVersion3 = "3"
+)
+
+var snmpAuthProtocols = map[string]gosnmp.SnmpV3AuthProtocol{
+	"MD5":    gosnmp.MD5,
+	"SHA":    gosnmp.SHA,
+	"SHA224": gosnmp.SHA224,
+	"SHA256": gosnmp.SHA256,
+	"SHA384": gosnmp.SHA384,
+	"SHA512": gosnmp.SHA512,
+}
+
+var snmpPrivProtocols = map[string]gosnmp.SnmpV3PrivProtocol{
+	"DES":     gosnmp.DES,
+	"AES":     gosnmp.AES,
+	"AES192":  gosnmp.AES192,
+	"AES256":  gosnmp.AES256,
+	"AES192C": gosnmp.AES192C,
+	"AES256C": gosnmp.AES256C,
+}
+
+// SNMPOIDConfiguration defines an OID to get and the assertions on its value
+type SNMPOIDConfiguration struct {
+	OID    string   `json:"oid"`
+	Equals string   `json:"equals,omitempty"`
+	Regexp []Regexp `json:"regexp,omitempty"`
+	// numeric range
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+}
+
+// SNMPHealthcheckConfiguration defines a SNMP healthcheck configuration
+type SNMPHealthcheckConfiguration struct {
+	Base `json:",inline" yaml:",inline"`
+	// can be an IP or a domain
+	Target    string `json:"target"`
+	Port      uint   `json:"port"`
+	Version   string `json:"version,omitempty"`
+	Community string `json:"community,omitempty"`
+	// SNMP v3 USM options
+	Username       string                 `json:"username,omitempty"`
+	AuthProtocol   string                 `json:"auth-protocol,omitempty" yaml:"auth-protocol,omitempty"`
+	AuthPassphrase string                 `json:"auth-passphrase,omitempty" yaml:"auth-passphrase,omitempty"`
+	PrivProtocol   string                 `json:"priv-protocol,omitempty" yaml:"priv-protocol,omitempty"`
+	PrivPassphrase string                 `json:"priv-passphrase,omitempty" yaml:"priv-passphrase,omitempty"`
+	OIDs           []SNMPOIDConfiguration `json:"oids"`
+	SourceIP       IP                     `json:"source-ip,omitempty" yaml:"source-ip,omitempty"`
+	Timeout        Duration               `json:"timeout"`
+}
+
+// Validate validates the healthcheck configuration
+func (config *SNMPHealthcheckConfiguration) Validate() error {
+	if err := config.Base.validate(); err != nil {
+		return err
+	}
+	if config.Target == "" {
+		return errors.New("The healthcheck target is missing")
+	}
+	if config.Port == 0 {
+		config.Port = 161
+	}
+	if config.Timeout == 0 {
+		return errors.New("The healthcheck timeout is missing")
+	}
+	if config.Version == "" {
+		config.Version = SNMPVersion2c
+	}
+	switch config.Version {
+	case SNMPVersion2c:
+		if config.Community == "" {
+			return errors.New("The healthcheck community is missing")
+		}
+	case SNMPVersion3:
+		if config.Username == "" {
+			return errors.New("The healthcheck username is missing")
+		}
+		if config.AuthProtocol != "" {
+			if _, ok := snmpAuthProtocols[config.AuthProtocol]; !ok {
+				return fmt.Errorf("Invalid healthcheck auth protocol %s", config.AuthProtocol)
+			}
+			if config.AuthPassphrase == "" {
+				return errors.New("The healthcheck auth passphrase is missing")
+			}
+		}
+		if config.PrivProtocol != "" {
+			if _, ok := snmpPrivProtocols[config.PrivProtocol]; !ok {
+				return fmt.Errorf("Invalid healthcheck priv protocol %s", config.PrivProtocol)
+			}
+			if config.AuthProtocol == "" {
+				return errors.New("The healthcheck auth protocol is required to use privacy")
+			}
+			if config.PrivPassphrase == "" {
+				return errors.New("The healthcheck priv passphrase is missing")
+			}
+		}
+	default:
+		return fmt.Errorf("Invalid healthcheck SNMP version %s", config.Version)
+	}
+	if len(config.OIDs) == 0 {
+		return errors.New("The healthcheck OIDs are missing")
+	}
+	for _, oid := range config.OIDs {
+		if oid.OID == "" {
+			return errors.New("The healthcheck OID is missing")
+		}
+		if oid.Min != nil && oid.Max != nil && *oid.Min > *oid.Max {
+			return fmt.Errorf("The healthcheck min value for %s should be lower than the max value", oid.OID)
+		}
+	}
+	if err := config.Base.validateTimeout(config.Timeout); err != nil {
+		return err
+	}
+	if !config.Base.OneOff && config.Base.Schedule == "" {
+		if config.Base.Interval < Duration(2*time.Second) {
+			return errors.New("The healthcheck interval should be greater than 2 second")
+		}
+		if config.Base.Interval < config.Timeout {
+			return errors.New("The healthcheck interval should be greater than the timeout")
+		}
+	}
+	return nil
+}
+
+// SNMPHealthcheck defines a SNMP healthcheck
+type SNMPHealthcheck struct {
+	Logger *zap.Logger
+	Config *SNMPHealthcheckConfiguration
+}
+
+// Summary returns an healthcheck summary
+func (h *SNMPHealthcheck) Summary() string {
+	summary := ""
+	if h.Config.Base.Description != "" {
+		summary = fmt.Sprintf("SNMP healthcheck %s on %s:%d", h.Config.Base.Description, h.Config.Target, h.Config.Port)
+
+	} else {
+		summary = fmt.Sprintf("SNMP healthcheck on %s:%d", h.Config.Target, h.Config.Port)
+	}
+
+	return summary
+}
+
+// Initialize the healthcheck.
+func (h *SNMPHealthcheck) Initialize() error {
+	return nil
+}
+
+// GetConfig get the config
+func (h *SNMPHealthcheck) GetConfig() interface{} {
+	return h.Config
+}
+
+// Base get the base configuration
+func (h *SNMPHealthcheck) Base() Base {
+	return h.Config.Base
+}
+
+// SetSource set the healthcheck source
+func (h *SNMPHealthcheck) SetSource(source string) {
+	h.Config.Base.Source = source
+}
+
+// Timeout returns the healthcheck timeout
+func (h *SNMPHealthcheck) Timeout() time.Duration {
+	return time.Duration(h.Config.Timeout)
+}
+
+// LogError logs an error with context
+func (h *SNMPHealthcheck) LogError(err error, message string) {
+	h.Logger.Error(err.Error(),
+		zap.String("extra", message),
+		zap.String("target", h.Config.Target),
+		zap.Uint("port", h.Config.Port),
+		zap.String("name", h.Config.Base.Name))
+}
+
+// LogDebug logs a message with context
+func (h *SNMPHealthcheck) LogDebug(message string) {
+	h.Logger.Debug(message,
+		zap.String("target", h.Config.Target),
+		zap.Uint("port", h.Config.Port),
+		zap.String("name", h.Config.Base.Name))
+}
+
+// LogInfo logs a message with context
+func (h *SNMPHealthcheck) LogInfo(message string) {
+	h.Logger.Info(message,
+		zap.String("target", h.Config.Target),
+		zap.Uint("port", h.Config.Port),
+		zap.String("name", h.Config.Base.Name))
+}
+
+// client builds the SNMP client
+func (h *SNMPHealthcheck) client(ctx context.Context) *gosnmp.GoSNMP {
+	port := h.Config.Port
+	if port == 0 {
+		port = 161
+	}
+	client := &gosnmp.GoSNMP{
+		Target:    h.Config.Target,
+		Port:      uint16(port),
+		Transport: "udp",
+		Context:   ctx,
+		Community: h.Config.Community,
+		Version:   gosnmp.Version2c,
+		Timeout:   time.Duration(h.Config.Timeout),
+		Retries:   0,
+		MaxOids:   gosnmp.MaxOids,
+	}
+	if h.Config.SourceIP != nil {
+		client.LocalAddr = net.JoinHostPort(net.IP(h.Config.SourceIP).String(), "0")
+	}
+	if h.Config.Version == SNMPVersion3 {
+		params := &gosnmp.UsmSecurityParameters{
+			UserName:               h.Config.Username,
+			AuthenticationProtocol: gosnmp.NoAuth,
+			PrivacyProtocol:        gosnmp.NoPriv,
+		}
+		flags := gosnmp.NoAuthNoPriv
+		if h.Config.AuthProtocol != "" {
+			flags = gosnmp.AuthNoPriv
+			params.AuthenticationProtocol = snmpAuthProtocols[h.Config.AuthProtocol]
+			params.AuthenticationPassphrase = h.Config.AuthPassphrase
+		}
+		if h.Config.PrivProtocol != "" {
+			flags = gosnmp.AuthPriv
+			params.PrivacyProtocol = snmpPrivProtocols[h.Config.PrivProtocol]
+			params.PrivacyPassphrase = h.Config.PrivPassphrase
+		}
+		client.Version = gosnmp.Version3
+		client.SecurityModel = gosnmp.UserSecurityModel
+		client.MsgFlags = flags
+		client.SecurityParameters = params
+	}
+	return client
+}
+
+// snmpValue converts a PDU value to a string or a number
+func snmpValue(pdu gosnmp.SnmpPDU) (interface{}, error) {
+	switch pdu.Type {
+	case gosnmp.NoSuchObject, gosnmp.NoSuchInstance, gosnmp.EndOfMibView, gosnmp.Null:
+		return nil, fmt.Errorf("The OID %s was not found", pdu.Name)
+	case gosnmp.OctetString:
+		return string(pdu.Value.([]byte)), nil
+	case gosnmp.Integer, gosnmp.Counter32, gosnmp.Gauge32, gosnmp.TimeTicks, gosnmp.Counter64, gosnmp.Uinteger32:
+		return pdu.Value, nil
+	}
+	return fmt.Sprintf("%v", pdu.Value), nil
+}
+
+// verifyValue verifies a value against the OID assertions
+func verifyValue(oid SNMPOIDConfiguration, value interface{}) error {
+	str := fmt.Sprintf("%v", value)
+	if oid.Equals != "" && str != oid.Equals {
+		return fmt.Errorf("The value of %s is %s, expected %s", oid.OID, str, oid.Equals)
+	}
+	for _, regex := range oid.Regexp {
+		r := regexp.Regexp(regex)
+		if !r.MatchString(str) {
+			return fmt.Errorf("The value of %s does not match regex %s: %s", oid.OID, r.String(), str)
+		}
+	}
+	if oid.Min != nil || oid.Max != nil {
+		if _, ok := value.(string); ok {
+			return fmt.Errorf("The value of %s is not a number: %s", oid.OID, str)
+		}
+		number, _ := gosnmp.ToBigInt(value).Float64()
+		if oid.Min != nil && number < *oid.Min {
+			return fmt.Errorf("The value of %s is %s, lower than %g", oid.OID, str, *oid.Min)
+		}
+		if oid.Max != nil && number > *oid.Max {
+			return fmt.Errorf("The value of %s is %s, greater than %g", oid.OID, str, *oid.Max)
+		}
+	}
+	return nil
+}
+
+// Execute executes an healthcheck on the given target
+func (h *SNMPHealthcheck) Execute(ctx context.Context) error {
+	_, err := h.ExecuteWithDetails(ctx)
+	return err
+}
+
+// ExecuteWithDetails executes the healthcheck, returning the OIDs values as
+// details
+func (h *SNMPHealthcheck) ExecuteWithDetails(ctx context.Context) (map[string]interface{}, error) {
+	h.LogDebug("start executing healthcheck")
+	client := h.client(ctx)
+	err := client.Connect()
+	if err != nil {
+		return nil, errors.Wrapf(err, "Fail to connect to %s", h.Config.Target)
+	}
+	defer client.Conn.Close()
+	oids := make([]string, 0, len(h.Config.OIDs))
+	for _, oid := range h.Config.OIDs {
+		oids = append(oids, oid.OID)
+	}
+	packet, err := client.Get(oids)
+	if err != nil {
+		return nil, errors.Wrapf(err, "SNMP get failed on %s", h.Config.Target)
+	}
+	if packet.Error != gosnmp.NoError {
+		return nil, fmt.Errorf("SNMP get failed on %s: %s", h.Config.Target, packet.Error.String())
+	}
+	values := map[string]interface{}{}
+	details := map[string]interface{}{
+		"values": values,
+	}
+	for _, pdu := range packet.Variables {
+		value, err := snmpValue(pdu)
+		if err != nil {
+			return details, err
+		}
+		values[strings.TrimPrefix(pdu.Name, ".")] = value
+	}
+	for _, oid := range h.Config.OIDs {
+		value, ok := values[strings.TrimPrefix(oid.OID, ".")]
+		if !ok {
+			return details, fmt.Errorf("The OID %s was not returned", oid.OID)
+		}
+		err := verifyValue(oid, value)
+		if err != nil {
+			return details, err
+		}
+	}
+	return details, nil
+}
+
+// NewSNMPHealthcheck creates a SNMP healthcheck from a logger and a configuration
+func NewSNMPHealthcheck(logger *zap.Logger, config *SNMPHealthcheckConfiguration) *SNMPHealthcheck {
+	return &SNMPHealthcheck{
+		Logger: logger,
+		Config: config,
+	}
+}
+
+// MarshalJSON marshal to json a SNMP healthcheck, the community and the passphrases are not exposed
+func (h *SNMPHealthcheck) MarshalJSON() ([]byte, error) {
+	config := h.Config.DeepCopy()
+	config.Community = ""
+	config.AuthPassphrase = ""
+	config.PrivPassphrase = ""
+	return json.Marshal(config)
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SNMPOIDConfiguration) DeepCopyInto(out *SNMPOIDConfiguration) {
+	*out = *in
+	if in.Regexp != nil {
+		in, out := &in.Regexp, &out.Regexp
+		*out = make([]Regexp, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Min != nil {
+		in, out := &in.Min, &out.Min
+		*out = new(float64)
+		**out = **in
+	}
+	if in.Max != nil {
+		in, out := &in.Max, &out.Max
+		*out = new(float64)
+		**out = **in
+	}
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SNMPHealthcheckConfiguration) DeepCopyInto(out *SNMPHealthcheckConfiguration) {
+	*out = *in
+	in.Base.DeepCopyInto(&out.Base)
+	if in.OIDs != nil {
+		in, out := &in.OIDs, &out.OIDs
+		*out = make([]SNMPOIDConfiguration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SourceIP != nil {
+		in, out := &in.SourceIP, &out.SourceIP
+		*out = make(IP, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SNMPHealthcheckConfiguration.
+func (in *SNMPHealthcheckConfiguration) DeepCopy() *SNMPHealthcheckConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(SNMPHealthcheckConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,174 @@
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"go.uber.org/zap"
+)
+
+// snmpTestAgent starts a SNMP v2c agent answering to get requests using the
+// values map
+func snmpTestAgent(t *testing.T, values map[string]gosnmp.SnmpPDU) (*net.UDPConn, uint) {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.ParseIP("127.0.0.1")})
+	if err != nil {
+		t.Fatalf("Fail to start the SNMP agent :\n%v", err)
+	}
+	decoder := &gosnmp.GoSNMP{
+		Version:   gosnmp.Version2c,
+		Community: "public",
+		Logger:    gosnmp.Default.Logger,
+	}
+	go func() {
+		buffer := make([]byte, 65535)
+		for {
+			n, addr, err := conn.ReadFromUDP(buffer)
+			if err != nil {
+				return
+			}
+			request, err := decoder.SnmpDecodePacket(buffer[:n])
+			if err != nil {
+				continue
+			}
+			variables := []gosnmp.SnmpPDU{}
+			for _, variable := range request.Variables {
+				pdu, ok := values[variable.Name]
+				if !ok {
+					pdu = gosnmp.SnmpPDU{Type: gosnmp.NoSuchObject}
+				}
+				pdu.Name = variable.Name
+				variables = append(variables, pdu)
+			}
+			response := &gosnmp.SnmpPacket{
+				Version:   gosnmp.Version2c,
+				Community: request.Community,
+				PDUType:   gosnmp.GetResponse,
+				RequestID: request.RequestID,
+				Variables: variables,
+				Logger:    gosnmp.Default.Logger,
+			}
+			b, err := response.MarshalMsg()
+			if err != nil {
+				return
+			}
+			_, err = conn.WriteToUDP(b, addr)
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return conn, uint(conn.LocalAddr().(*net.UDPAddr).Port)
+}
+
+func TestSNMPExecute(t *testing.T) {
+	conn, port := snmpTestAgent(t, map[string]gosnmp.SnmpPDU{
+		".1.3.6.1.2.1.1.1.0": {Type: gosnmp.OctetString, Value: []byte("switch-01 firmware 4.2")},
+		".1.3.6.1.2.1.1.3.0": {Type: gosnmp.TimeTicks, Value: uint32(123456)},
+	})
+	defer conn.Close()
+	min := float64(1000)
+	max := float64(200000)
+	h := NewSNMPHealthcheck(
+		zap.NewExample(),
+		&SNMPHealthcheckConfiguration{
+			Target:    "127.0.0.1",
+			Port:      port,
+			Community: "public",
+			OIDs: []SNMPOIDConfiguration{
+				{
+					OID:    "1.3.6.1.2.1.1.1.0",
+					Regexp: []Regexp{Regexp(*regexp.MustCompile("firmware 4"))},
+				},
+				{
+					OID: "1.3.6.1.2.1.1.3.0",
+					Min: &min,
+					Max: &max,
+				},
+			},
+			Timeout: Duration(time.Second * 2),
+		})
+	err := h.Initialize()
+	if err != nil {
+		t.Fatalf("Initialization error :\n%v", err)
+	}
+	details, err := h.ExecuteWithDetails(context.Background())
+	if err != nil {
+		t.Fatalf("healthcheck error :\n%v", err)
+	}
+	values := details["values"].(map[string]interface{})
+	if values["1.3.6.1.2.1.1.1.0"] != "switch-01 firmware 4.2" {
+		t.Fatalf("Invalid details: %v", details)
+	}
+	if values["1.3.6.1.2.1.1.3.0"] != uint32(123456) {
+		t.Fatalf("Invalid details: %v", details)
+	}
+	h.Config.OIDs[1].Max = &min
+	err = h.Execute(context.Background())
+	if err == nil {
+		t.Fatalf("healthcheck was expected to fail")
+	}
+	h.Config.OIDs = []SNMPOIDConfiguration{
+		{OID: "1.3.6.1.2.1.1.1.0", Equals: "switch-02"},
+	}
+	err = h.Execute(context.Background())
+	if err == nil {
+		t.Fatalf("healthcheck was expected to fail")
+	}
+	h.Config.OIDs = []SNMPOIDConfiguration{
+		{OID: "1.3.6.1.2.1.1.5.0"},
+	}
+	err = h.Execute(context.Background())
+	if err == nil {
+		t.Fatalf("healthcheck was expected to fail")
+	}
+}
+
+func TestSNMPValidate(t *testing.T) {
+	oids := []SNMPOIDConfiguration{{OID: "1.3.6.1.2.1.1.1.0"}}
+	min := float64(10)
+	max := float64(1)
+	cases := []SNMPHealthcheckConfiguration{
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Community: "public", OIDs: oids, Timeout: Duration(time.Second)},
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Target: "127.0.0.1", OIDs: oids, Timeout: Duration(time.Second)},
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Target: "127.0.0.1", Community: "public", Timeout: Duration(time.Second)},
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Target: "127.0.0.1", Community: "public", OIDs: []SNMPOIDConfiguration{{OID: "1.3.6.1.2.1.1.3.0", Min: &min, Max: &max}}, Timeout: Duration(time.Second)},
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Target: "127.0.0.1", Version: "1", Community: "public", OIDs: oids, Timeout: Duration(time.Second)},
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Target: "127.0.0.1", Version: SNMPVersion3, OIDs: oids, Timeout: Duration(time.Second)},
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Target: "127.0.0.1", Version: SNMPVersion3, Username: "foo", AuthProtocol: "SHA", AuthPassphrase: "password", PrivProtocol: "foo", PrivPassphrase: "password", OIDs: oids, Timeout: Duration(time.Second)},
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Target: "127.0.0.1", Version: SNMPVersion3, Username: "foo", PrivProtocol: "AES", PrivPassphrase: "password", OIDs: oids, Timeout: Duration(time.Second)},
+	}
+	for i := range cases {
+		err := cases[i].Validate()
+		if err == nil {
+			t.Fatalf("Validation was expected to fail for case %d", i)
+		}
+	}
+	valid := SNMPHealthcheckConfiguration{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Target: "127.0.0.1", Version: SNMPVersion3, Username: "foo", AuthProtocol: "SHA", AuthPassphrase: "password", PrivProtocol: "AES", PrivPassphrase: "password", OIDs: oids, Timeout: Duration(time.Second)}
+	err := valid.Validate()
+	if err != nil {
+		t.Fatalf("Validation error :\n%v", err)
+	}
+	if valid.Port != 161 {
+		t.Fatalf("Invalid default port %d", valid.Port)
+	}
+}
+
+func TestSNMPMarshalJSONRedacted(t *testing.T) {
+	h := NewSNMPHealthcheck(zap.NewExample(), &SNMPHealthcheckConfiguration{Community: "s3cr3t", AuthPassphrase: "s3cr3t", PrivPassphrase: "s3cr3t"})
+	out, err := json.Marshal(h)
+	if err != nil {
+		t.Fatalf("Fail to marshal the healthcheck :\n%v", err)
+	}
+	if strings.Contains(string(out), "s3cr3t") {
+		t.Fatalf("The credentials were exposed: %s", out)
+	}
+	if h.Config.Community != "s3cr3t" {
+		t.Fatalf("The healthcheck configuration was modified")
+	}
+}
@@ -0,0 +1,379 @@
+package healthcheck
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/ssh"
+)
+
+// SSHHealthcheckConfiguration defines a SSH healthcheck configuration
+type SSHHealthcheckConfiguration struct {
+	Base `json:",inline" yaml:",inline"`
+	// can be an IP or a domain
+	Target       string   `json:"target"`
+	Port         uint     `json:"port"`
+	SourceIP     IP       `json:"source-ip,omitempty" yaml:"source-ip,omitempty"`
+	BannerRegexp []Regexp `json:"banner-regexp,omitempty" yaml:"banner-regexp,omitempty"`
+	// SHA256 fingerprints of the accepted host keys, for example
+	// SHA256:uNiVztksCsDhcc0u9e8BujQXVUpKZIDTMczCvj3tD2s
+	Fingerprints []string `json:"fingerprints,omitempty"`
+	User         string   `json:"user,omitempty"`
+	// path to the private key used for authentication
+	PrivateKey string   `json:"private-key,omitempty" yaml:"private-key,omitempty"`
+	Passphrase string   `json:"passphrase,omitempty"`
+	Command    string   `json:"command,omitempty"`
+	ExitCode   int      `json:"exit-code" yaml:"exit-code"`
+	Timeout    Duration `json:"timeout"`
+}
+
+// Validate validates the healthcheck configuration
+func (config *SSHHealthcheckConfiguration) Validate() error {
+	if err := config.Base.validate(); err != nil {
+		return err
+	}
+	if config.Target == "" {
+		return errors.New("The healthcheck target is missing")
+	}
+	if config.Port == 0 {
+		return errors.New("The healthcheck port is missing")
+	}
+	if config.Timeout == 0 {
+		return errors.New("The healthcheck timeout is missing")
+	}
+	for _, fingerprint := range config.Fingerprints {
+		if !strings.HasPrefix(fingerprint, "SHA256:") {
+			return fmt.Errorf("Invalid healthcheck fingerprint %s", fingerprint)
+		}
+	}
+	if config.PrivateKey != "" && config.User == "" {
+		return errors.New("The healthcheck user is missing")
+	}
+	if config.Command != "" && config.PrivateKey == "" {
+		return errors.New("The healthcheck private key is required to execute a command")
+	}
+	if err := config.Base.validateTimeout(config.Timeout); err != nil {
+		return err
+	}
+	if !config.Base.OneOff && config.Base.Schedule == "" {
+		if config.Base.Interval < Duration(2*time.Second) {
+			return errors.New("The healthcheck interval should be greater than 2 second")
+		}
+		if config.Base.Interval < config.Timeout {
+			return errors.New("The healthcheck interval should be greater than the timeout")
+		}
+	}
+	return nil
+}
+
+// SSHHealthcheck defines a SSH healthcheck
+type SSHHealthcheck struct {
+	Logger *zap.Logger
+	Config *SSHHealthcheckConfiguration
+	URL    string
+
+	dialer *net.Dialer
+	signer ssh.Signer
+}
+
+// Summary returns an healthcheck summary
+func (h *SSHHealthcheck) Summary() string {
+	summary := ""
+	if h.Config.Base.Description != "" {
+		summary = fmt.Sprintf("SSH healthcheck %s on %s:%d", h.Config.Base.Description, h.Config.Target, h.Config.Port)
+
+	} else {
+		summary = fmt.Sprintf("SSH healthcheck on %s:%d", h.Config.Target, h.Config.Port)
+	}
+
+	return summary
+}
+
+// loadSSHSigner reads a private key from a file
+func loadSSHSigner(path string, passphrase string) (ssh.Signer, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Fail to read the private key %s", path)
+	}
+	if passphrase != "" {
+		signer, err := ssh.ParsePrivateKeyWithPassphrase(content, []byte(passphrase))
+		if err != nil {
+			return nil, errors.Wrapf(err, "Fail to parse the private key %s", path)
+		}
+		return signer, nil
+	}
+	signer, err := ssh.ParsePrivateKey(content)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Fail to parse the private key %s", path)
+	}
+	return signer, nil
+}
+
+// Initialize the healthcheck.
+func (h *SSHHealthcheck) Initialize() error {
+	h.URL = net.JoinHostPort(h.Config.Target, fmt.Sprintf("%d", h.Config.Port))
+	dialer, err := newDialer(h.Config.SourceIP)
+	if err != nil {
+		return err
+	}
+	h.dialer = dialer
+	if h.Config.PrivateKey != "" {
+		signer, err := loadSSHSigner(h.Config.PrivateKey, h.Config.Passphrase)
+		if err != nil {
+			return err
+		}
+		h.signer = signer
+	}
+	return nil
+}
+
+// GetConfig get the config
+func (h *SSHHealthcheck) GetConfig() interface{} {
+	return h.Config
+}
+
+// Base get the base configuration
+func (h *SSHHealthcheck) Base() Base {
+	return h.Config.Base
+}
+
+// SetSource set the healthcheck source
+func (h *SSHHealthcheck) SetSource(source string) {
+	h.Config.Base.Source = source
+}
+
+// Timeout returns the healthcheck timeout
+func (h *SSHHealthcheck) Timeout() time.Duration {
+	return time.Duration(h.Config.Timeout)
+}
+
+// LogError logs an error with context
+func (h *SSHHealthcheck) LogError(err error, message string) {
+	h.Logger.Error(err.Error(),
+		zap.String("extra", message),
+		zap.String("target", h.Config.Target),
+		zap.Uint("port", h.Config.Port),
+		zap.String("name", h.Config.Base.Name))
+}
+
+// LogDebug logs a message with context
+func (h *SSHHealthcheck) LogDebug(message string) {
+	h.Logger.Debug(message,
+		zap.String("target", h.Config.Target),
+		zap.Uint("port", h.Config.Port),
+		zap.String("name", h.Config.Base.Name))
+}
+
+// LogInfo logs a message with context
+func (h *SSHHealthcheck) LogInfo(message string) {
+	h.Logger.Info(message,
+		zap.String("target", h.Config.Target),
+		zap.Uint("port", h.Config.Port),
+		zap.String("name", h.Config.Base.Name))
+}
+
+// bannerConn records the beginning of the data read from a connection in
+// order to extract the server banner
+type bannerConn struct {
+	net.Conn
+	lock   sync.Mutex
+	banner bytes.Buffer
+}
+
+func (c *bannerConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.lock.Lock()
+	if c.banner.Len() < 512 {
+		c.banner.Write(b[:n])
+	}
+	c.lock.Unlock()
+	return n, err
+}
+
+// Banner returns the server identification line, if received
+func (c *bannerConn) Banner() string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, line := range strings.Split(c.banner.String(), "\n") {
+		if strings.HasPrefix(line, "SSH-") {
+			return strings.TrimRight(line, "\r")
+		}
+	}
+	return ""
+}
+
+// verifyHostKey verifies a host key against the pinned fingerprints
+func (h *SSHHealthcheck) verifyHostKey(key ssh.PublicKey) error {
+	if len(h.Config.Fingerprints) == 0 {
+		return nil
+	}
+	fingerprint := ssh.FingerprintSHA256(key)
+	for _, f := range h.Config.Fingerprints {
+		if f == fingerprint {
+			return nil
+		}
+	}
+	return fmt.Errorf("The host key fingerprint %s is not in the pinned fingerprints", fingerprint)
+}
+
+// runCommand executes the configured command and verifies its exit code
+func (h *SSHHealthcheck) runCommand(client *ssh.Client, details map[string]interface{}) error {
+	session, err := client.NewSession()
+	if err != nil {
+		return errors.Wrap(err, "Fail to open a SSH session")
+	}
+	defer session.Close()
+	exitCode := 0
+	err = session.Run(h.Config.Command)
+	if err != nil {
+		var exitErr *ssh.ExitError
+		if !errors.As(err, &exitErr) {
+			return errors.Wrap(err, "Fail to execute the command")
+		}
+		exitCode = exitErr.ExitStatus()
+	}
+	details["exit-code"] = exitCode
+	if exitCode != h.Config.ExitCode {
+		return fmt.Errorf("The command exited with code %d, expected %d", exitCode, h.Config.ExitCode)
+	}
+	return nil
+}
+
+// Execute executes an healthcheck on the given target
+func (h *SSHHealthcheck) Execute(ctx context.Context) error {
+	_, err := h.ExecuteWithDetails(ctx)
+	return err
+}
+
+// sshAuthRejected returns true if the error is the rejection of the client
+// authentication by the server
+func sshAuthRejected(err error) bool {
+	// the ssh package does not expose a typed error for this case
+	return strings.Contains(err.Error(), "ssh: unable to authenticate")
+}
+
+// ExecuteWithDetails executes the healthcheck, returning the server banner,
+// the host key fingerprint and the command exit code as details
+func (h *SSHHealthcheck) ExecuteWithDetails(ctx context.Context) (map[string]interface{}, error) {
+	h.LogDebug("start executing healthcheck")
+	details := map[string]interface{}{}
+	rawConn, err := h.dialer.DialContext(ctx, "tcp", h.URL)
+	if err != nil {
+		return details, errors.Wrapf(err, "SSH connection failed on %s", h.URL)
+	}
+	conn := &bannerConn{Conn: rawConn}
+	defer conn.Close()
+	deadline, _ := ctx.Deadline()
+	err = conn.SetDeadline(deadline)
+	if err != nil {
+		return details, errors.Wrap(err, "Fail to set the connection deadline")
+	}
+	keyExchanged := false
+	user := h.Config.User
+	if user == "" {
+		user = "cabourotte"
+	}
+	config := &ssh.ClientConfig{
+		User: user,
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			details["fingerprint"] = ssh.FingerprintSHA256(key)
+			err := h.verifyHostKey(key)
+			if err == nil {
+				keyExchanged = true
+			}
+			return err
+		},
+		Timeout: time.Duration(h.Config.Timeout),
+	}
+	if h.signer != nil {
+		config.Auth = []ssh.AuthMethod{ssh.PublicKeys(h.signer)}
+	}
+	sshConn, channels, requests, err := ssh.NewClientConn(conn, h.URL, config)
+	banner := conn.Banner()
+	if banner != "" {
+		details["banner"] = banner
+	}
+	if err != nil {
+		// without credentials, the authentication is expected to be
+		// rejected once the key exchange is done
+		if !(keyExchanged && h.signer == nil && sshAuthRejected(err)) {
+			return details, errors.Wrapf(err, "SSH handshake failed on %s", h.URL)
+		}
+	}
+	if banner == "" {
+		return details, errors.New("No SSH banner received")
+	}
+	for _, regex := range h.Config.BannerRegexp {
+		r := regexp.Regexp(regex)
+		if !r.MatchString(banner) {
+			return details, fmt.Errorf("The SSH banner does not match regex %s: %s", r.String(), banner)
+		}
+	}
+	if sshConn == nil {
+		return details, nil
+	}
+	client := ssh.NewClient(sshConn, channels, requests)
+	defer client.Close()
+	if h.Config.Command != "" {
+		return details, h.runCommand(client, details)
+	}
+	return details, nil
+}
+
+// NewSSHHealthcheck creates a SSH healthcheck from a logger and a configuration
+func NewSSHHealthcheck(logger *zap.Logger, config *SSHHealthcheckConfiguration) *SSHHealthcheck {
+	return &SSHHealthcheck{
+		Logger: logger,
+		Config: config,
+	}
+}
+
+// MarshalJSON marshal to json a SSH healthcheck, the private key passphrase is not exposed
+func (h *SSHHealthcheck) MarshalJSON() ([]byte, error) {
+	config := h.Config.DeepCopy()
+	config.Passphrase = ""
+	return json.Marshal(config)
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SSHHealthcheckConfiguration) DeepCopyInto(out *SSHHealthcheckConfiguration) {
+	*out = *in
+	in.Base.DeepCopyInto(&out.Base)
+	if in.SourceIP != nil {
+		in, out := &in.SourceIP, &out.SourceIP
+		*out = make(IP, len(*in))
+		copy(*out, *in)
+	}
+	if in.BannerRegexp != nil {
+		in, out := &in.BannerRegexp, &out.BannerRegexp
+		*out = make([]Regexp, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Fingerprints != nil {
+		in, out := &in.Fingerprints, &out.Fingerprints
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SSHHealthcheckConfiguration.
+func (in *SSHHealthcheckConfiguration) DeepCopy() *SSHHealthcheckConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(SSHHealthcheckConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,278 @@
+package healthcheck
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/crypto/ssh"
+)
+
+// sshTestKey generates a private key, writes it to a file and returns the
+// file path and the signer
+func sshTestKey(t *testing.T) (string, ssh.Signer) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Fail to generate the key :\n%v", err)
+	}
+	block, err := ssh.MarshalPrivateKey(key, "")
+	if err != nil {
+		t.Fatalf("Fail to marshal the key :\n%v", err)
+	}
+	path := filepath.Join(t.TempDir(), "id_ed25519")
+	err = os.WriteFile(path, pem.EncodeToMemory(block), 0600)
+	if err != nil {
+		t.Fatalf("Fail to write the key :\n%v", err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatalf("Fail to create the signer :\n%v", err)
+	}
+	return path, signer
+}
+
+// sshTestServer starts a SSH server accepting the given client key and
+// executing the commands locally with /bin/sh
+func sshTestServer(t *testing.T, clientKey ssh.PublicKey) (net.Listener, uint, ssh.Signer) {
+	_, hostSigner := sshTestKey(t)
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if bytes.Equal(key.Marshal(), clientKey.Marshal()) {
+				return nil, nil
+			}
+			return nil, ssh.ErrNoAuth
+		},
+		ServerVersion: "SSH-2.0-CabourotteTest",
+	}
+	config.AddHostKey(hostSigner)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Fail to start the SSH server :\n%v", err)
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				_, channels, requests, err := ssh.NewServerConn(conn, config)
+				if err != nil {
+					return
+				}
+				go ssh.DiscardRequests(requests)
+				for newChannel := range channels {
+					channel, requests, err := newChannel.Accept()
+					if err != nil {
+						return
+					}
+					go sshTestSession(channel, requests)
+				}
+			}(conn)
+		}
+	}()
+	return listener, uint(listener.Addr().(*net.TCPAddr).Port), hostSigner
+}
+
+func sshTestSession(channel ssh.Channel, requests <-chan *ssh.Request) {
+	defer channel.Close()
+	for req := range requests {
+		if req.Type != "exec" {
+			_ = req.Reply(false, nil)
+			continue
+		}
+		_ = req.Reply(true, nil)
+		length := binary.BigEndian.Uint32(req.Payload)
+		cmd := exec.Command("/bin/sh", "-c", string(req.Payload[4:4+length]))
+		cmd.Stdin = channel
+		cmd.Stdout = channel
+		cmd.Stderr = channel.Stderr()
+		status := make([]byte, 4)
+		err := cmd.Run()
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			binary.BigEndian.PutUint32(status, uint32(exitErr.ExitCode()))
+		}
+		_, _ = channel.SendRequest("exit-status", false, status)
+		return
+	}
+}
+
+func TestSSHExecute(t *testing.T) {
+	keyPath, clientSigner := sshTestKey(t)
+	listener, port, hostSigner := sshTestServer(t, clientSigner.PublicKey())
+	defer listener.Close()
+	r := regexp.MustCompile("CabourotteTest")
+	h := NewSSHHealthcheck(
+		zap.NewExample(),
+		&SSHHealthcheckConfiguration{
+			Target:       "127.0.0.1",
+			Port:         port,
+			BannerRegexp: []Regexp{Regexp(*r)},
+			Fingerprints: []string{ssh.FingerprintSHA256(hostSigner.PublicKey())},
+			Timeout:      Duration(time.Second * 2),
+		})
+	err := h.Initialize()
+	if err != nil {
+		t.Fatalf("Initialization error :\n%v", err)
+	}
+	// key exchange only
+	details, err := h.ExecuteWithDetails(context.Background())
+	if err != nil {
+		t.Fatalf("healthcheck error :\n%v", err)
+	}
+	if details["banner"] != "SSH-2.0-CabourotteTest" || details["fingerprint"] != ssh.FingerprintSHA256(hostSigner.PublicKey()) {
+		t.Fatalf("Invalid details: %v", details)
+	}
+	// authentication and command
+	h.Config.User = "cabourotte"
+	h.Config.PrivateKey = keyPath
+	h.Config.Command = "exit 3"
+	h.Config.ExitCode = 3
+	err = h.Initialize()
+	if err != nil {
+		t.Fatalf("Initialization error :\n%v", err)
+	}
+	details, err = h.ExecuteWithDetails(context.Background())
+	if err != nil {
+		t.Fatalf("healthcheck error :\n%v", err)
+	}
+	if details["exit-code"] != 3 {
+		t.Fatalf("Invalid details: %v", details)
+	}
+	h.Config.ExitCode = 0
+	err = h.Execute(context.Background())
+	if err == nil {
+		t.Fatalf("healthcheck was expected to fail")
+	}
+	// changed host key
+	h.Config.Fingerprints = []string{"SHA256:uNiVztksCsDhcc0u9e8BujQXVUpKZIDTMczCvj3tD2s"}
+	h.Config.Command = ""
+	err = h.Execute(context.Background())
+	if err == nil {
+		t.Fatalf("healthcheck was expected to fail")
+	}
+}
+
+func TestSSHExecuteNoBanner(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Fail to start the server :\n%v", err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			time.Sleep(time.Second)
+			conn.Close()
+		}
+	}()
+	h := NewSSHHealthcheck(
+		zap.NewExample(),
+		&SSHHealthcheckConfiguration{
+			Target:  "127.0.0.1",
+			Port:    uint(listener.Addr().(*net.TCPAddr).Port),
+			Timeout: Duration(time.Millisecond * 300),
+		})
+	err = h.Initialize()
+	if err != nil {
+		t.Fatalf("Initialization error :\n%v", err)
+	}
+	err = h.Execute(context.Background())
+	if err == nil {
+		t.Fatalf("healthcheck was expected to fail")
+	}
+}
+
+func TestSSHExecuteConnectionClosed(t *testing.T) {
+	_, hostSigner := sshTestKey(t)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Fail to start the SSH server :\n%v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			// the connection is closed after the key exchange,
+			// instead of rejecting the authentication
+			config := &ssh.ServerConfig{
+				PasswordCallback: func(_ ssh.ConnMetadata, _ []byte) (*ssh.Permissions, error) {
+					return nil, ssh.ErrNoAuth
+				},
+				AuthLogCallback: func(_ ssh.ConnMetadata, _ string, _ error) {
+					conn.Close()
+				},
+				ServerVersion: "SSH-2.0-CabourotteTest",
+			}
+			config.AddHostKey(hostSigner)
+			go func() {
+				_, _, _, _ = ssh.NewServerConn(conn, config)
+			}()
+		}
+	}()
+	h := NewSSHHealthcheck(
+		zap.NewExample(),
+		&SSHHealthcheckConfiguration{
+			Target:       "127.0.0.1",
+			Port:         uint(listener.Addr().(*net.TCPAddr).Port),
+			Fingerprints: []string{ssh.FingerprintSHA256(hostSigner.PublicKey())},
+			Timeout:      Duration(time.Second * 2),
+		})
+	err = h.Initialize()
+	if err != nil {
+		t.Fatalf("Initialization error :\n%v", err)
+	}
+	details, err := h.ExecuteWithDetails(context.Background())
+	if err == nil {
+		t.Fatalf("healthcheck was expected to fail")
+	}
+	if details["fingerprint"] != ssh.FingerprintSHA256(hostSigner.PublicKey()) {
+		t.Fatalf("The key exchange was expected to succeed: %v", details)
+	}
+}
+
+func TestSSHValidate(t *testing.T) {
+	cases := []SSHHealthcheckConfiguration{
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Target: "127.0.0.1", Port: 22, Timeout: Duration(time.Second), Fingerprints: []string{"MD5:aa"}},
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Target: "127.0.0.1", Port: 22, Timeout: Duration(time.Second), Command: "true"},
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Target: "127.0.0.1", Port: 22, Timeout: Duration(time.Second), PrivateKey: "/tmp/key"},
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Target: "127.0.0.1", Timeout: Duration(time.Second)},
+	}
+	for i := range cases {
+		err := cases[i].Validate()
+		if err == nil {
+			t.Fatalf("Validation was expected to fail for case %d", i)
+		}
+	}
+}
+
+func TestSSHMarshalJSONRedacted(t *testing.T) {
+	h := NewSSHHealthcheck(zap.NewExample(), &SSHHealthcheckConfiguration{Passphrase: "s3cr3t"})
+	out, err := json.Marshal(h)
+	if err != nil {
+		t.Fatalf("Fail to marshal the healthcheck :\n%v", err)
+	}
+	if strings.Contains(string(out), "s3cr3t") {
+		t.Fatalf("The credentials were exposed: %s", out)
+	}
+	if h.Config.Passphrase != "s3cr3t" {
+		t.Fatalf("The healthcheck configuration was modified")
+	}
+}
@@ -9,25 +9,22 @@ import (
 
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
-
-	"gopkg.in/tomb.v2"
 )
 
 // TCPHealthcheckConfiguration defines a TCP healthcheck configuration
 type TCPHealthcheckConfiguration struct {
 	Base `json:",inline" yaml:",inline"`
 	// can be an IP or a domain
-	Target     string   `json:"target"`
-	Port       uint     `json:"port"`
-	SourceIP   IP       `json:"source-ip,omitempty" yaml:"source-ip,omitempty"`
-	Timeout    Duration `json:"timeout"`
-	ShouldFail bool     `json:"should-fail" yaml:"should-fail"`
+	Target   string   `json:"target"`
+	Port     uint     `json:"port"`
+	SourceIP IP       `json:"source-ip,omitempty" yaml:"source-ip,omitempty"`
+	Timeout  Duration `json:"timeout"`
 }
 
 // Validate validates the healthcheck configuration
 func (config *TCPHealthcheckConfiguration) Validate() error {
-	if config.Base.Name == "" {
-		return errors.New("The healthcheck name is missing")
+	if err := config.Base.validate(); err != nil {
+		return err
 	}
 	if config.Target == "" {
 		return errors.New("The healthcheck target is missing")
@@ -38,7 +35,10 @@ func (config *TCPHealthcheckConfiguration) Validate() error {
 	if config.Timeout == 0 {
 		return errors.New("The healthcheck timeout is missing")
 	}
-	if !config.Base.OneOff {
+	if err := config.Base.validateTimeout(config.Timeout); err != nil {
+		return err
+	}
+	if !config.Base.OneOff && config.Base.Schedule == "" {
 		if config.Base.Interval < Duration(2*time.Second) {
 			return errors.New("The healthcheck interval should be greater than 2 second")
 		}
@@ -56,7 +56,6 @@ type TCPHealthcheck struct {
 	URL    string
 
 	Tick *time.Ticker
-	t    tomb.Tomb
 }
 
 // buildURL build the target URL for the TCP healthcheck, depending of its
@@ -75,10 +74,6 @@ func (h *TCPHealthcheck) Summary() string {
 		summary = fmt.Sprintf("TCP healthcheck on %s:%d", h.Config.Target, h.Config.Port)
 	}
 
-	if h.Config.ShouldFail {
-		summary = summary + ". This healthcheck has should-fail=true."
-	}
-
 	return summary
 }
 
@@ -103,6 +98,11 @@ func (h *TCPHealthcheck) SetSource(source string) {
 	h.Config.Base.Source = source
 }
 
+// Timeout returns the healthcheck timeout
+func (h *TCPHealthcheck) Timeout() time.Duration {
+	return time.Duration(h.Config.Timeout)
+}
+
 // LogError logs an error with context
 func (h *TCPHealthcheck) LogError(err error, message string) {
 	h.Logger.Error(err.Error(),
@@ -129,9 +129,8 @@ func (h *TCPHealthcheck) LogInfo(message string) {
 }
 
 // Execute executes an healthcheck on the given target
-func (h *TCPHealthcheck) Execute() error {
+func (h *TCPHealthcheck) Execute(ctx context.Context) error {
 	h.LogDebug("start executing healthcheck")
-	ctx := h.t.Context(context.TODO())
 	dialer := net.Dialer{}
 	if h.Config.SourceIP != nil {
 		srcIP := net.IP(h.Config.SourceIP).String()
@@ -143,20 +142,11 @@ func (h *TCPHealthcheck) Execute() error {
 			LocalAddr: addr,
 		}
 	}
-	timeoutCtx, cancel := context.WithTimeout(ctx, time.Duration(h.Config.Timeout))
-	defer cancel()
-	conn, err := dialer.DialContext(timeoutCtx, "tcp", h.URL)
-	if h.Config.ShouldFail {
-		if err == nil {
-			defer conn.Close()
-			return fmt.Errorf("TCP check is successful on %s but an error was expected", h.URL)
-		}
-	} else {
-		if err != nil {
-			return errors.Wrapf(err, "TCP connection failed on %s", h.URL)
-		}
-		defer conn.Close()
+	conn, err := dialer.DialContext(ctx, "tcp", h.URL)
+	if err != nil {
+		return errors.Wrapf(err, "TCP connection failed on %s", h.URL)
 	}
+	defer conn.Close()
 	return nil
 }
 
@@ -1,6 +1,7 @@
 package healthcheck
 
 import (
+	"context"
 	"net"
 	"net/http"
 	"net/http/httptest"
@@ -47,7 +48,7 @@ func TestTCPExecuteSuccess(t *testing.T) {
 		},
 	}
 	h.buildURL()
-	err = h.Execute()
+	err = h.Execute(context.Background())
 	if err != nil {
 		t.Fatalf("healthcheck error :\n%v", err)
 	}
@@ -73,7 +74,7 @@ func TestTCPExecuteSuccessSourceIP(t *testing.T) {
 		},
 	}
 	h.buildURL()
-	err = h.Execute()
+	err = h.Execute(context.Background())
 	if err != nil {
 		t.Fatalf("healthcheck error :\n%v", err)
 	}
@@ -106,7 +107,7 @@ func TestTCPv6ExecuteSuccess(t *testing.T) {
 		},
 	}
 	h.buildURL()
-	err = h.Execute()
+	err = h.Execute(context.Background())
 	if err != nil {
 		t.Fatalf("healthcheck error :\n%v", err)
 	}
@@ -137,7 +138,7 @@ func TestTCPStartStop(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Fail to create the component\n%v", err)
 	}
-	component.startWrapper(wrapper)
+	component.startWrapper(wrapper, false)
 	err = wrapper.Stop()
 	if err != nil {
 		t.Fatalf("Fail to stop the healthcheck\n%v", err)
@@ -148,14 +149,16 @@ func TestTCPExecuteSuccessShoulddFail(t *testing.T) {
 	h := TCPHealthcheck{
 		Logger: zap.NewExample(),
 		Config: &TCPHealthcheckConfiguration{
-			ShouldFail: true,
-			Port:       80,
-			Target:     "doesnotexist.mcorbin.fr",
-			Timeout:    Duration(time.Second * 2),
+			Base: Base{
+				ShouldFail: true,
+			},
+			Port:    80,
+			Target:  "doesnotexist.mcorbin.fr",
+			Timeout: Duration(time.Second * 2),
 		},
 	}
 	h.buildURL()
-	err := h.Execute()
+	_, err := Execute(context.Background(), &h)
 	if err != nil {
 		t.Fatalf("healthcheck error :\n%v", err)
 	}
@@ -11,7 +11,6 @@ import (
 	"github.com/appclacks/cabourotte/tls"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
-	"gopkg.in/tomb.v2"
 )
 
 // TLSHealthcheckConfiguration defines a TLS healthcheck configuration
@@ -28,6 +27,9 @@ type TLSHealthcheckConfiguration struct {
 	ServerName      string   `json:"server-name,omitempty" yaml:"server-name"`
 	Insecure        bool     `json:"insecure"`
 	ExpirationDelay Duration `json:"expiration-delay" yaml:"expiration-delay"`
+	// the result status is warning if the certificate expires within this
+	// delay
+	ExpirationWarningDelay Duration `json:"expiration-warning-delay,omitempty" yaml:"expiration-warning-delay,omitempty"`
 }
 
 // TLSHealthcheck defines a TLS healthcheck
@@ -38,13 +40,12 @@ type TLSHealthcheck struct {
 	TLSConfig *cryptotls.Config
 
 	Tick *time.Ticker
-	t    tomb.Tomb
 }
 
 // Validate validates the healthcheck configuration
 func (config *TLSHealthcheckConfiguration) Validate() error {
-	if config.Base.Name == "" {
-		return errors.New("The healthcheck name is missing")
+	if err := config.Base.validate(); err != nil {
+		return err
 	}
 	if config.Target == "" {
 		return errors.New("The healthcheck target is missing")
@@ -55,7 +56,13 @@ func (config *TLSHealthcheckConfiguration) Validate() error {
 	if config.Timeout == 0 {
 		return errors.New("The healthcheck timeout is missing")
 	}
-	if !config.Base.OneOff {
+	if config.ExpirationWarningDelay != 0 && config.ExpirationWarningDelay <= config.ExpirationDelay {
+		return errors.New("The healthcheck expiration warning delay should be greater than the expiration delay")
+	}
+	if err := config.Base.validateTimeout(config.Timeout); err != nil {
+		return err
+	}
+	if !config.Base.OneOff && config.Base.Schedule == "" {
 		if config.Base.Interval < Duration(2*time.Second) {
 			return errors.New("The healthcheck interval should be greater than 2 second")
 		}
@@ -80,6 +87,11 @@ func (h *TLSHealthcheck) SetSource(source string) {
 	h.Config.Base.Source = source
 }
 
+// Timeout returns the healthcheck timeout
+func (h *TLSHealthcheck) Timeout() time.Duration {
+	return time.Duration(h.Config.Timeout)
+}
+
 // Summary returns an healthcheck summary
 func (h *TLSHealthcheck) Summary() string {
 	summary := ""
@@ -141,10 +153,9 @@ func (h *TLSHealthcheck) LogInfo(message string) {
 }
 
 // Execute executes an healthcheck on the given target
-func (h *TLSHealthcheck) Execute() error {
+func (h *TLSHealthcheck) Execute(ctx context.Context) error {
 	h.LogDebug("start executing healthcheck")
 	dialer := net.Dialer{}
-	ctx := h.t.Context(context.TODO())
 	if h.Config.SourceIP != nil {
 		srcIP := net.IP(h.Config.SourceIP).String()
 		addr, err := net.ResolveTCPAddr("tcp", fmt.Sprintf("%s:0", srcIP))
@@ -157,20 +168,18 @@ func (h *TLSHealthcheck) Execute() error {
 		}
 	}
 
-	timeoutCtx, cancel := context.WithTimeout(ctx, time.Duration(h.Config.Timeout))
-	defer cancel()
-	conn, err := dialer.DialContext(timeoutCtx, "tcp", h.URL)
+	conn, err := dialer.DialContext(ctx, "tcp", h.URL)
 	if err != nil {
 		return errors.Wrapf(err, "TLS connection failed on %s", h.URL)
 	}
 	defer conn.Close()
 	tlsConn := cryptotls.Client(conn, h.TLSConfig)
 	defer tlsConn.Close()
-	err = tlsConn.Handshake()
+	err = tlsConn.HandshakeContext(ctx)
 	if err != nil {
 		return errors.Wrapf(err, "TLS handshake failed on %s", h.URL)
 	}
-	if h.Config.ExpirationDelay != 0 {
+	if h.Config.ExpirationDelay != 0 || h.Config.ExpirationWarningDelay != 0 {
 		state := tlsConn.ConnectionState()
 		expirationTime := time.Time{}
 		for _, cert := range state.PeerCertificates {
@@ -178,9 +187,17 @@ func (h *TLSHealthcheck) Execute() error {
 				expirationTime = cert.NotAfter
 			}
 		}
-		expirationTimeLimit := time.Now().Add(time.Duration(h.Config.ExpirationDelay))
-		if expirationTime.Before(expirationTimeLimit) {
-			return fmt.Errorf("The certificate for %s will expire at %s", h.URL, expirationTime.String())
+		if h.Config.ExpirationDelay != 0 {
+			expirationTimeLimit := time.Now().Add(time.Duration(h.Config.ExpirationDelay))
+			if expirationTime.Before(expirationTimeLimit) {
+				return fmt.Errorf("The certificate for %s will expire at %s", h.URL, expirationTime.String())
+			}
+		}
+		if h.Config.ExpirationWarningDelay != 0 {
+			warningTimeLimit := time.Now().Add(time.Duration(h.Config.ExpirationWarningDelay))
+			if expirationTime.Before(warningTimeLimit) {
+				return NewStatusError(StatusWarning, fmt.Errorf("The certificate for %s will expire at %s", h.URL, expirationTime.String()))
+			}
 		}
 	}
 
@@ -1,6 +1,7 @@
 package healthcheck
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
@@ -44,7 +45,7 @@ func TestTLSExecuteError(t *testing.T) {
 		},
 	}
 	h.buildURL()
-	err = h.Execute()
+	err = h.Execute(context.Background())
 	if err == nil {
 		t.Fatalf("Was expecting an error")
 	}
@@ -60,8 +61,46 @@ func TestTLSExecuteErrorNoTarget(t *testing.T) {
 		},
 	}
 	h.buildURL()
-	err := h.Execute()
+	err := h.Execute(context.Background())
 	if err == nil {
 		t.Fatalf("Was expecting an error")
 	}
 }
+
+func TestTLSExecuteExpirationWarning(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	port, err := strconv.ParseUint(strings.Split(ts.URL, ":")[2], 10, 16)
+	if err != nil {
+		t.Fatalf("error getting HTTP server port :\n%v", err)
+	}
+	h := NewTLSHealthcheck(
+		zap.NewExample(),
+		&TLSHealthcheckConfiguration{
+			Port:                   uint(port),
+			Target:                 "127.0.0.1",
+			Insecure:               true,
+			Timeout:                Duration(time.Second * 2),
+			ExpirationWarningDelay: Duration(time.Hour * 24 * 365 * 200),
+		})
+	err = h.Initialize()
+	if err != nil {
+		t.Fatalf("Initialization error :\n%v", err)
+	}
+	err = h.Execute(context.Background())
+	if ErrorStatus(err) != StatusWarning {
+		t.Fatalf("healthcheck was expected to return a warning :\n%v", err)
+	}
+	result := NewResult(h, 0, err)
+	if !result.Success || result.Status != StatusWarning {
+		t.Fatalf("Invalid result: %v", result)
+	}
+	h.Config.ExpirationWarningDelay = Duration(time.Hour)
+	err = h.Execute(context.Background())
+	if err != nil {
+		t.Fatalf("healthcheck error :\n%v", err)
+	}
+}
@@ -114,6 +114,63 @@ func (p Protocol) MarshalJSON() ([]byte, error) {
 	return nil, errors.New(fmt.Sprintf("Unknown protocol %d", p))
 }
 
+// WebSocketScheme is the healthcheck websocket scheme
+type WebSocketScheme int
+
+const (
+	// WS the WebSocket protocol
+	WS WebSocketScheme = 1 + iota
+	// WSS the WebSocket protocol over TLS
+	WSS
+)
+
+// UnmarshalYAML read a websocket scheme fom yaml
+func (s *WebSocketScheme) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var raw string
+	if err := unmarshal(&raw); err != nil {
+		return errors.Wrap(err, "Unable to read the healthcheck scheme")
+	}
+	if raw == "ws" {
+		*s = WS
+	} else if raw == "wss" {
+		*s = WSS
+	} else {
+		return errors.New(fmt.Sprintf("Invalid scheme %s", raw))
+	}
+	return nil
+}
+
+// UnmarshalText unmarshal a websocket scheme
+func (s *WebSocketScheme) UnmarshalText(text []byte) error {
+	if len(text) < 2 {
+		return errors.New(fmt.Sprintf("Invalid scheme %s", text))
+	}
+	raw := unQuote(text)
+	if raw == "ws" {
+		*s = WS
+	} else if raw == "wss" {
+		*s = WSS
+	} else {
+		return errors.New(fmt.Sprintf("Invalid scheme %s", raw))
+	}
+	return nil
+}
+
+// UnmarshalJSON marshal to json a websocket scheme
+func (s *WebSocketScheme) UnmarshalJSON(text []byte) error {
+	return s.UnmarshalText(text)
+}
+
+// MarshalJSON marshal to json a websocket scheme
+func (s WebSocketScheme) MarshalJSON() ([]byte, error) {
+	if s == WS {
+		return json.Marshal("ws")
+	} else if s == WSS {
+		return json.Marshal("wss")
+	}
+	return nil, errors.New(fmt.Sprintf("Unknown scheme %d", s))
+}
+
 type Regexp regexp.Regexp
 
 // UnmarshalText unmarshal a duration
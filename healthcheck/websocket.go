@@ -0,0 +1,342 @@
+package healthcheck
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/appclacks/cabourotte/tls"
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+)
+
+// WebSocketHealthcheckConfiguration defines a WebSocket healthcheck configuration
+type WebSocketHealthcheckConfiguration struct {
+	Base `json:",inline" yaml:",inline"`
+	// can be an IP or a domain
+	Target       string            `json:"target"`
+	Port         uint              `json:"port"`
+	Scheme       WebSocketScheme   `json:"scheme"`
+	Path         string            `json:"path,omitempty"`
+	Headers      map[string]string `json:"headers,omitempty"`
+	Subprotocols []string          `json:"subprotocols,omitempty"`
+	SourceIP     IP                `json:"source-ip,omitempty" yaml:"source-ip,omitempty"`
+	// the message to send once connected
+	Message string `json:"message,omitempty"`
+	// if true, the message is base64 encoded and sent as a binary message
+	Binary         bool     `json:"binary,omitempty"`
+	ResponseRegexp []Regexp `json:"response-regexp,omitempty" yaml:"response-regexp,omitempty"`
+	Insecure       bool     `json:"insecure"`
+	ServerName     string   `json:"server-name"`
+	Timeout        Duration `json:"timeout"`
+	Key            string   `json:"key,omitempty"`
+	Cert           string   `json:"cert,omitempty"`
+	Cacert         string   `json:"cacert,omitempty"`
+}
+
+// Validate validates the healthcheck configuration
+func (config *WebSocketHealthcheckConfiguration) Validate() error {
+	if err := config.Base.validate(); err != nil {
+		return err
+	}
+	if config.Target == "" {
+		return errors.New("The healthcheck target is missing")
+	}
+	if config.Port == 0 {
+		return errors.New("The healthcheck port is missing")
+	}
+	if config.Timeout == 0 {
+		return errors.New("The healthcheck timeout is missing")
+	}
+	if config.Scheme == 0 {
+		config.Scheme = WS
+	}
+	if config.Scheme != WS && config.Scheme != WSS {
+		return errors.New("The healthcheck scheme should be ws or wss")
+	}
+	if config.Binary {
+		_, err := base64.StdEncoding.DecodeString(config.Message)
+		if err != nil {
+			return errors.Wrap(err, "The healthcheck binary message should be base64 encoded")
+		}
+	}
+	if err := config.Base.validateTimeout(config.Timeout); err != nil {
+		return err
+	}
+	if !config.Base.OneOff && config.Base.Schedule == "" {
+		if config.Base.Interval < Duration(2*time.Second) {
+			return errors.New("The healthcheck interval should be greater than 2 second")
+		}
+		if config.Base.Interval < config.Timeout {
+			return errors.New("The healthcheck interval should be greater than the timeout")
+		}
+	}
+	if !((config.Key != "" && config.Cert != "") ||
+		(config.Key == "" && config.Cert == "")) {
+		return errors.New("Invalid certificates")
+	}
+	return nil
+}
+
+// WebSocketHealthcheck defines a WebSocket healthcheck
+type WebSocketHealthcheck struct {
+	Logger *zap.Logger
+	Config *WebSocketHealthcheckConfiguration
+	URL    string
+
+	dialer  *websocket.Dialer
+	message []byte
+}
+
+// Summary returns an healthcheck summary
+func (h *WebSocketHealthcheck) Summary() string {
+	summary := ""
+	if h.Config.Base.Description != "" {
+		summary = fmt.Sprintf("WebSocket healthcheck %s on %s:%d", h.Config.Base.Description, h.Config.Target, h.Config.Port)
+
+	} else {
+		summary = fmt.Sprintf("WebSocket healthcheck on %s:%d", h.Config.Target, h.Config.Port)
+	}
+
+	return summary
+}
+
+// Initialize the healthcheck.
+func (h *WebSocketHealthcheck) Initialize() error {
+	scheme := "ws"
+	if h.Config.Scheme == WSS {
+		scheme = "wss"
+	}
+	h.URL = fmt.Sprintf(
+		"%s://%s%s",
+		scheme,
+		net.JoinHostPort(h.Config.Target, fmt.Sprintf("%d", h.Config.Port)),
+		h.Config.Path)
+	dialer, err := newDialer(h.Config.SourceIP)
+	if err != nil {
+		return err
+	}
+	tlsConfig, err := tls.GetTLSConfig(h.Config.Key, h.Config.Cert, h.Config.Cacert, h.Config.ServerName, h.Config.Insecure)
+	if err != nil {
+		return err
+	}
+	h.dialer = &websocket.Dialer{
+		NetDialContext:  dialer.DialContext,
+		TLSClientConfig: tlsConfig,
+		Subprotocols:    h.Config.Subprotocols,
+	}
+	h.message = []byte(h.Config.Message)
+	if h.Config.Binary {
+		h.message, err = base64.StdEncoding.DecodeString(h.Config.Message)
+		if err != nil {
+			return errors.Wrap(err, "Fail to decode the binary message")
+		}
+	}
+	return nil
+}
+
+// GetConfig get the config
+func (h *WebSocketHealthcheck) GetConfig() interface{} {
+	return h.Config
+}
+
+// Base get the base configuration
+func (h *WebSocketHealthcheck) Base() Base {
+	return h.Config.Base
+}
+
+// SetSource set the healthcheck source
+func (h *WebSocketHealthcheck) SetSource(source string) {
+	h.Config.Base.Source = source
+}
+
+// Timeout returns the healthcheck timeout
+func (h *WebSocketHealthcheck) Timeout() time.Duration {
+	return time.Duration(h.Config.Timeout)
+}
+
+// LogError logs an error with context
+func (h *WebSocketHealthcheck) LogError(err error, message string) {
+	h.Logger.Error(err.Error(),
+		zap.String("extra", message),
+		zap.String("target", h.Config.Target),
+		zap.Uint("port", h.Config.Port),
+		zap.String("name", h.Config.Base.Name))
+}
+
+// LogDebug logs a message with context
+func (h *WebSocketHealthcheck) LogDebug(message string) {
+	h.Logger.Debug(message,
+		zap.String("target", h.Config.Target),
+		zap.Uint("port", h.Config.Port),
+		zap.String("name", h.Config.Base.Name))
+}
+
+// LogInfo logs a message with context
+func (h *WebSocketHealthcheck) LogInfo(message string) {
+	h.Logger.Info(message,
+		zap.String("target", h.Config.Target),
+		zap.Uint("port", h.Config.Port),
+		zap.String("name", h.Config.Base.Name))
+}
+
+// waitResponse reads messages until one matches all the configured regexps
+func (h *WebSocketHealthcheck) waitResponse(conn *websocket.Conn, details map[string]interface{}) error {
+	start := time.Now()
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return errors.Wrap(err, "Fail to read the WebSocket response")
+		}
+		if _, ok := details["first-message-time"]; !ok {
+			details["first-message-time"] = time.Since(start).String()
+		}
+		matches := true
+		for _, regex := range h.Config.ResponseRegexp {
+			r := regexp.Regexp(regex)
+			if !r.Match(message) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return nil
+		}
+	}
+}
+
+// closeConnection sends a close message and waits for the server close
+// message
+func (h *WebSocketHealthcheck) closeConnection(conn *websocket.Conn, deadline time.Time, details map[string]interface{}) error {
+	message := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	err := conn.WriteControl(websocket.CloseMessage, message, deadline)
+	if err != nil {
+		return errors.Wrap(err, "Fail to close the WebSocket connection")
+	}
+	for {
+		_, _, err := conn.ReadMessage()
+		if err != nil {
+			var closeErr *websocket.CloseError
+			if errors.As(err, &closeErr) {
+				details["close-code"] = closeErr.Code
+				if closeErr.Code != websocket.CloseNormalClosure {
+					return fmt.Errorf("The WebSocket connection was closed with code %d", closeErr.Code)
+				}
+				return nil
+			}
+			return errors.Wrap(err, "The WebSocket connection was not closed cleanly")
+		}
+	}
+}
+
+// Execute executes an healthcheck on the given target
+func (h *WebSocketHealthcheck) Execute(ctx context.Context) error {
+	_, err := h.ExecuteWithDetails(ctx)
+	return err
+}
+
+// ExecuteWithDetails executes the healthcheck, returning the handshake status,
+// the time to the first message and the close code as details
+func (h *WebSocketHealthcheck) ExecuteWithDetails(ctx context.Context) (map[string]interface{}, error) {
+	h.LogDebug("start executing healthcheck")
+	headers := http.Header{}
+	headers.Set("User-Agent", "Cabourotte")
+	for k, v := range h.Config.Headers {
+		headers.Set(k, v)
+	}
+	details := map[string]interface{}{}
+	conn, response, err := h.dialer.DialContext(ctx, h.URL, headers)
+	if response != nil {
+		details["handshake-status"] = response.StatusCode
+	}
+	if err != nil {
+		return details, errors.Wrap(err, "WebSocket handshake failed")
+	}
+	defer conn.Close()
+	details["subprotocol"] = conn.Subprotocol()
+	deadline, _ := ctx.Deadline()
+	err = conn.SetReadDeadline(deadline)
+	if err != nil {
+		return details, errors.Wrap(err, "Fail to set the WebSocket read deadline")
+	}
+	if h.Config.Message != "" {
+		err = conn.SetWriteDeadline(deadline)
+		if err != nil {
+			return details, errors.Wrap(err, "Fail to set the WebSocket write deadline")
+		}
+		messageType := websocket.TextMessage
+		if h.Config.Binary {
+			messageType = websocket.BinaryMessage
+		}
+		err = conn.WriteMessage(messageType, h.message)
+		if err != nil {
+			return details, errors.Wrap(err, "Fail to send the WebSocket message")
+		}
+	}
+	if len(h.Config.ResponseRegexp) != 0 {
+		err = h.waitResponse(conn, details)
+		if err != nil {
+			return details, err
+		}
+	}
+	return details, h.closeConnection(conn, deadline, details)
+}
+
+// NewWebSocketHealthcheck creates a WebSocket healthcheck from a logger and a configuration
+func NewWebSocketHealthcheck(logger *zap.Logger, config *WebSocketHealthcheckConfiguration) *WebSocketHealthcheck {
+	return &WebSocketHealthcheck{
+		Logger: logger,
+		Config: config,
+	}
+}
+
+// MarshalJSON marshal to json a WebSocket healthcheck
+func (h *WebSocketHealthcheck) MarshalJSON() ([]byte, error) {
+	return json.Marshal(h.Config)
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebSocketHealthcheckConfiguration) DeepCopyInto(out *WebSocketHealthcheckConfiguration) {
+	*out = *in
+	in.Base.DeepCopyInto(&out.Base)
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Subprotocols != nil {
+		in, out := &in.Subprotocols, &out.Subprotocols
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SourceIP != nil {
+		in, out := &in.SourceIP, &out.SourceIP
+		*out = make(IP, len(*in))
+		copy(*out, *in)
+	}
+	if in.ResponseRegexp != nil {
+		in, out := &in.ResponseRegexp, &out.ResponseRegexp
+		*out = make([]Regexp, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebSocketHealthcheckConfiguration.
+func (in *WebSocketHealthcheckConfiguration) DeepCopy() *WebSocketHealthcheckConfiguration {
+	if in == nil {
+		return nil
+	}
+	out := new(WebSocketHealthcheckConfiguration)
+	in.DeepCopyInto(out)
+	return out
+}
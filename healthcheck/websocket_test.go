@@ -0,0 +1,165 @@
+package healthcheck
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v2"
+)
+
+func websocketTestServer(t *testing.T) (*httptest.Server, uint) {
+	upgrader := websocket.Upgrader{Subprotocols: []string{"chat"}}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Foo") != "Bar" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		err = conn.WriteMessage(websocket.TextMessage, []byte("welcome"))
+		if err != nil {
+			return
+		}
+		for {
+			messageType, message, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			err = conn.WriteMessage(messageType, append([]byte("echo "), message...))
+			if err != nil {
+				return
+			}
+		}
+	}))
+	port, err := strconv.ParseUint(strings.Split(ts.URL, ":")[2], 10, 16)
+	if err != nil {
+		t.Fatalf("error getting HTTP server port :\n%v", err)
+	}
+	return ts, uint(port)
+}
+
+func TestWebSocketExecuteSuccess(t *testing.T) {
+	ts, port := websocketTestServer(t)
+	defer ts.Close()
+	r := regexp.MustCompile("^echo ping$")
+	h := NewWebSocketHealthcheck(
+		zap.NewExample(),
+		&WebSocketHealthcheckConfiguration{
+			Target:         "127.0.0.1",
+			Port:           port,
+			Scheme:         WS,
+			Path:           "/",
+			Headers:        map[string]string{"Foo": "Bar"},
+			Subprotocols:   []string{"chat"},
+			Message:        "ping",
+			ResponseRegexp: []Regexp{Regexp(*r)},
+			Timeout:        Duration(time.Second * 2),
+		})
+	err := h.Initialize()
+	if err != nil {
+		t.Fatalf("Initialization error :\n%v", err)
+	}
+	details, err := h.ExecuteWithDetails(context.Background())
+	if err != nil {
+		t.Fatalf("healthcheck error :\n%v", err)
+	}
+	if details["handshake-status"] != http.StatusSwitchingProtocols || details["subprotocol"] != "chat" || details["close-code"] != websocket.CloseNormalClosure {
+		t.Fatalf("Invalid details: %v", details)
+	}
+	if _, ok := details["first-message-time"]; !ok {
+		t.Fatalf("Invalid details: %v", details)
+	}
+	// binary message
+	h.Config.Binary = true
+	h.Config.Message = "cGluZw=="
+	err = h.Initialize()
+	if err != nil {
+		t.Fatalf("Initialization error :\n%v", err)
+	}
+	err = h.Execute(context.Background())
+	if err != nil {
+		t.Fatalf("healthcheck error :\n%v", err)
+	}
+}
+
+func TestWebSocketExecuteFailure(t *testing.T) {
+	ts, port := websocketTestServer(t)
+	defer ts.Close()
+	r := regexp.MustCompile("^echo pong$")
+	h := NewWebSocketHealthcheck(
+		zap.NewExample(),
+		&WebSocketHealthcheckConfiguration{
+			Target:         "127.0.0.1",
+			Port:           port,
+			Scheme:         WS,
+			Path:           "/",
+			Headers:        map[string]string{"Foo": "Bar"},
+			Message:        "ping",
+			ResponseRegexp: []Regexp{Regexp(*r)},
+			Timeout:        Duration(time.Millisecond * 500),
+		})
+	err := h.Initialize()
+	if err != nil {
+		t.Fatalf("Initialization error :\n%v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), h.Timeout())
+	defer cancel()
+	err = h.Execute(ctx)
+	if err == nil {
+		t.Fatalf("healthcheck was expected to fail")
+	}
+	// handshake failure
+	h.Config.Headers = nil
+	details, err := h.ExecuteWithDetails(context.Background())
+	if err == nil {
+		t.Fatalf("healthcheck was expected to fail")
+	}
+	if details["handshake-status"] != http.StatusForbidden {
+		t.Fatalf("Invalid details: %v", details)
+	}
+}
+
+func TestWebSocketValidate(t *testing.T) {
+	cases := []WebSocketHealthcheckConfiguration{
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Target: "127.0.0.1", Port: 80, Timeout: Duration(time.Second), Scheme: WebSocketScheme(42)},
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Target: "127.0.0.1", Port: 80, Timeout: Duration(time.Second), Binary: true, Message: "%%%"},
+		{Base: Base{Name: "foo", Interval: Duration(time.Second * 10)}, Target: "127.0.0.1", Timeout: Duration(time.Second)},
+	}
+	for i := range cases {
+		err := cases[i].Validate()
+		if err == nil {
+			t.Fatalf("Validation was expected to fail for case %d", i)
+		}
+	}
+}
+
+func TestWebSocketUnmarshalScheme(t *testing.T) {
+	var config WebSocketHealthcheckConfiguration
+	err := yaml.Unmarshal([]byte("scheme: wss"), &config)
+	if err != nil {
+		t.Fatalf("Unmarshal error :\n%v", err)
+	}
+	if config.Scheme != WSS {
+		t.Fatalf("Invalid scheme: %d", config.Scheme)
+	}
+	err = yaml.Unmarshal([]byte("scheme: https"), &config)
+	if err == nil {
+		t.Fatalf("Unmarshalling an http scheme was expected to fail")
+	}
+	var httpConfig HTTPHealthcheckConfiguration
+	err = yaml.Unmarshal([]byte("protocol: ws"), &httpConfig)
+	if err == nil {
+		t.Fatalf("Unmarshalling a websocket protocol was expected to fail")
+	}
+}
@@ -1,33 +1,340 @@
 package healthcheck
 
 import (
+	"context"
+	"fmt"
+	"sync"
 	"time"
 
+	"github.com/robfig/cron/v3"
 	"gopkg.in/tomb.v2"
 )
 
+// timeoutGracePeriod is the delay given to an healthcheck to return once its
+// context is done, before the wrapper reports a timeout
+const timeoutGracePeriod = 500 * time.Millisecond
+
 // Wrapper Wrap an healthcheck
 type Wrapper struct {
 	healthcheck Healthcheck
-	Tick        *time.Ticker
-	t           tomb.Tomb
+	Timer       *time.Timer
+	// tracks the goroutine scheduling the executions, nil while the
+	// healthcheck is paused
+	t *tomb.Tomb
+
+	// cancelled when the healthcheck is stopped
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// nil if the healthcheck is executed using its interval
+	schedule cron.Schedule
+	// serializes the periodic and the on-demand executions
+	execLock sync.Mutex
+
+	lock     sync.RWMutex
+	state    state
+	interval time.Duration
+	next     time.Time
+	paused   bool
+}
+
+// RuntimeState is the state of an healthcheck computed from its previous
+// executions
+type RuntimeState struct {
+	Status          Status     `json:"status,omitempty"`
+	RawStatus       Status     `json:"raw-status,omitempty"`
+	Flapping        bool       `json:"flapping"`
+	Paused          bool       `json:"paused"`
+	CurrentInterval *Duration  `json:"current-interval,omitempty"`
+	NextExecution   *time.Time `json:"next-execution,omitempty"`
+}
+
+// state tracks the debounced status of an healthcheck between executions
+type state struct {
+	status      Status
+	raw         Status
+	failures    uint
+	successes   uint
+	transitions []time.Time
+	flapping    bool
+}
+
+// update updates the state from a new result, replacing the result status by
+// the debounced status
+func (s *state) update(base Base, result *Result, now time.Time) {
+	raw := result.Status
+	result.RawStatus = raw
+	if s.status == "" {
+		s.status = raw
+	} else if raw.Success() != s.raw.Success() {
+		s.transitions = append(s.transitions, now)
+	}
+	s.raw = raw
+	fall := base.Fall
+	if fall == 0 {
+		fall = 1
+	}
+	rise := base.Rise
+	if rise == 0 {
+		rise = 1
+	}
+	if raw.Success() {
+		s.successes++
+		s.failures = 0
+		if s.status.Success() || s.successes >= rise {
+			s.status = raw
+		}
+	} else {
+		s.failures++
+		s.successes = 0
+		if !s.status.Success() || s.failures >= fall {
+			s.status = raw
+		}
+	}
+	windowStart := now.Add(-time.Duration(base.FlapWindow))
+	i := 0
+	for i < len(s.transitions) && s.transitions[i].Before(windowStart) {
+		i++
+	}
+	s.transitions = s.transitions[i:]
+	result.Status = s.status
+	result.Success = s.status.Success()
+	s.flapping = base.FlapThreshold != 0 && uint(len(s.transitions)) >= base.FlapThreshold
+	result.Flapping = s.flapping
 }
 
 // NewWrapper creates a new wrapper struct
 func NewWrapper(healthcheck Healthcheck) *Wrapper {
+	ctx, cancel := context.WithCancel(context.Background())
 	return &Wrapper{
 		healthcheck: healthcheck,
+		ctx:         ctx,
+		cancel:      cancel,
 	}
 }
 
+// abortedError is returned by the wrapper when the healthcheck did not
+// return on time or was stopped
+type abortedError struct {
+	message string
+}
+
+// Error returns the error message
+func (e *abortedError) Error() string {
+	return e.message
+}
+
+// attempt is the outcome of an healthcheck execution
+type attempt struct {
+	details map[string]interface{}
+	err     error
+}
+
+// executeAttempt executes the healthcheck once. Its context is cancelled
+// after the healthcheck timeout or when the healthcheck is removed, and an
+// error is returned if the healthcheck does not return on time, even if it
+// ignores its context.
+func (w *Wrapper) executeAttempt() (map[string]interface{}, error) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	timeout := w.healthcheck.Timeout()
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(w.ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(w.ctx)
+	}
+	defer cancel()
+	done := make(chan attempt, 1)
+	go func() {
+		details, err := execute(ctx, w.healthcheck)
+		done <- attempt{details: details, err: err}
+	}()
+	var result attempt
+	select {
+	case result = <-done:
+	case <-ctx.Done():
+		select {
+		case result = <-done:
+		case <-time.After(timeoutGracePeriod):
+			if w.ctx.Err() != nil {
+				result.err = &abortedError{message: "The healthcheck was cancelled"}
+			} else {
+				result.err = &abortedError{message: fmt.Sprintf("The healthcheck timed out after %s", timeout.String())}
+			}
+		}
+	}
+	return invert(w.healthcheck, result.details, result.err)
+}
+
+// execute executes the healthcheck, retrying failed executions. The retries
+// are stopped when the dying channel is closed. The returned duration is the
+// duration of the last attempt.
+func (w *Wrapper) execute(dying <-chan struct{}) (*Result, time.Duration) {
+	base := w.healthcheck.Base()
+	attempts := 0
+	for {
+		attempts++
+		start := time.Now()
+		details, err := w.executeAttempt()
+		duration := time.Since(start)
+		result := NewResult(
+			w.healthcheck,
+			duration.Milliseconds(),
+			err)
+		result.Details = details
+		result.checkDuration(base, duration)
+		result.Attempts = attempts
+		if result.Success || uint(attempts) > base.Retries {
+			return result, duration
+		}
+		w.healthcheck.LogDebug(fmt.Sprintf("attempt %d failed, retrying: %s", attempts, result.Message))
+		select {
+		case <-time.After(base.retryDelay(uint(attempts))):
+		case <-dying:
+			return result, duration
+		case <-w.ctx.Done():
+			return result, duration
+		}
+		// the delay may have expired at the same time the wrapper was
+		// stopped
+		select {
+		case <-dying:
+			return result, duration
+		default:
+		}
+		if w.ctx.Err() != nil {
+			return result, duration
+		}
+	}
+}
+
+// run executes the healthcheck and updates its state, returning the result
+// and the duration of the last attempt
+func (w *Wrapper) run(dying <-chan struct{}) (*Result, time.Duration) {
+	w.execLock.Lock()
+	defer w.execLock.Unlock()
+	result, duration := w.execute(dying)
+	w.update(result, time.Now())
+	return result, duration
+}
+
+// update updates the healthcheck state from a new result and returns the
+// interval to wait before the next execution
+func (w *Wrapper) update(result *Result, now time.Time) time.Duration {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	base := w.healthcheck.Base()
+	w.state.update(base, result, now)
+	w.interval = time.Duration(base.Interval)
+	// the failure interval is used until the failure is confirmed and
+	// until the recovery is confirmed
+	if base.FailureInterval != 0 && (!result.Success || !result.RawStatus.Success()) {
+		w.interval = time.Duration(base.FailureInterval)
+	}
+	return w.interval
+}
+
+// currentInterval returns the interval to wait before the next execution,
+// 0 if the next execution is defined by the schedule
+func (w *Wrapper) currentInterval() time.Duration {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+	if w.interval == 0 {
+		return time.Duration(w.healthcheck.Base().Interval)
+	}
+	return w.interval
+}
+
+// RuntimeState returns the runtime state of the healthcheck
+func (w *Wrapper) RuntimeState() *RuntimeState {
+	interval := w.currentInterval()
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+	state := &RuntimeState{
+		Status:    w.state.status,
+		RawStatus: w.state.raw,
+		Flapping:  w.state.flapping,
+		Paused:    w.paused,
+	}
+	if interval != 0 {
+		currentInterval := Duration(interval)
+		state.CurrentInterval = &currentInterval
+	}
+	if !w.paused && !w.next.IsZero() {
+		next := w.next
+		state.NextExecution = &next
+	}
+	return state
+}
+
+// resetTimer schedules the next execution of the healthcheck, using the
+// schedule if the interval is 0
+func (w *Wrapper) resetTimer(interval time.Duration, start time.Time) {
+	if !w.Timer.Stop() {
+		select {
+		case <-w.Timer.C:
+		default:
+		}
+	}
+	now := time.Now()
+	var next time.Time
+	if interval == 0 && w.schedule != nil {
+		next = w.schedule.Next(now)
+	} else {
+		next = start.Add(interval)
+		if next.Before(now) {
+			next = now
+		}
+	}
+	w.lock.Lock()
+	w.next = next
+	w.lock.Unlock()
+	w.Timer.Reset(next.Sub(now))
+}
+
+// stopLoop stops the goroutine scheduling the executions and waits for it.
+// An execution in progress is not interrupted but its retries are.
+func (w *Wrapper) stopLoop() error {
+	w.lock.Lock()
+	t := w.t
+	w.t = nil
+	w.lock.Unlock()
+	if t == nil {
+		return nil
+	}
+	t.Kill(nil)
+	err := t.Wait()
+	w.Timer.Stop()
+	return err
+}
+
 // Stop an Healthcheck wrapper
 func (w *Wrapper) Stop() error {
-	w.Tick.Stop()
-	w.t.Kill(nil)
-	err := w.t.Wait()
+	// the results being handled are not dropped halfway
+	w.lock.Lock()
+	w.cancel()
+	w.lock.Unlock()
+	err := w.stopLoop()
 	if err != nil {
 		return err
 	}
+	if closable, ok := w.healthcheck.(ClosableHealthcheck); ok {
+		return closable.Close()
+	}
 	return nil
 
 }
+
+// Paused returns true if the healthcheck is paused
+func (w *Wrapper) Paused() bool {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+	return w.paused
+}
+
+// setPaused marks the healthcheck as paused or running
+func (w *Wrapper) setPaused(paused bool) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.paused = paused
+}
@@ -59,11 +59,37 @@ func (c *Configuration) UnmarshalYAML(unmarshal func(interface{}) error) error {
 
 // BulkPayload the paylaod for bulk requests fo healthchecks
 type BulkPayload struct {
-	DNSChecks     []healthcheck.DNSHealthcheckConfiguration     `json:"dns-checks"`
-	CommandChecks []healthcheck.CommandHealthcheckConfiguration `json:"command-checks"`
-	TCPChecks     []healthcheck.TCPHealthcheckConfiguration     `json:"tcp-checks"`
-	HTTPChecks    []healthcheck.HTTPHealthcheckConfiguration    `json:"http-checks"`
-	TLSChecks     []healthcheck.TLSHealthcheckConfiguration     `json:"tls-checks"`
+	DNSChecks        []healthcheck.DNSHealthcheckConfiguration        `json:"dns-checks"`
+	CommandChecks    []healthcheck.CommandHealthcheckConfiguration    `json:"command-checks"`
+	TCPChecks        []healthcheck.TCPHealthcheckConfiguration        `json:"tcp-checks"`
+	HTTPChecks       []healthcheck.HTTPHealthcheckConfiguration       `json:"http-checks"`
+	TLSChecks        []healthcheck.TLSHealthcheckConfiguration        `json:"tls-checks"`
+	FileChecks       []healthcheck.FileHealthcheckConfiguration       `json:"file-checks"`
+	ProcessChecks    []healthcheck.ProcessHealthcheckConfiguration    `json:"process-checks"`
+	HostChecks       []healthcheck.HostHealthcheckConfiguration       `json:"host-checks"`
+	PrometheusChecks []healthcheck.PrometheusHealthcheckConfiguration `json:"prometheus-checks"`
+	WebSocketChecks  []healthcheck.WebSocketHealthcheckConfiguration  `json:"websocket-checks"`
+	MQTTChecks       []healthcheck.MQTTHealthcheckConfiguration       `json:"mqtt-checks"`
+	LDAPChecks       []healthcheck.LDAPHealthcheckConfiguration       `json:"ldap-checks"`
+	SSHChecks        []healthcheck.SSHHealthcheckConfiguration        `json:"ssh-checks"`
+	NTPChecks        []healthcheck.NTPHealthcheckConfiguration        `json:"ntp-checks"`
+	SNMPChecks       []healthcheck.SNMPHealthcheckConfiguration       `json:"snmp-checks"`
+	DockerChecks     []healthcheck.DockerHealthcheckConfiguration     `json:"docker-checks"`
+}
+
+// RunPayload the payload to execute the healthchecks matching a label
+// selector
+type RunPayload struct {
+	Labels map[string]string `json:"labels"`
+}
+
+// Validate validates the payload to execute healthchecks. The selector is
+// required to not execute all the healthchecks at once.
+func (p *RunPayload) Validate() error {
+	if len(p.Labels) == 0 {
+		return errors.New("The labels selector is required")
+	}
+	return nil
 }
 
 // Validate validates the payload for bulk requests
@@ -119,5 +145,115 @@ func (p *BulkPayload) Validate() error {
 			return errors.New(msg)
 		}
 	}
+	for _, config := range p.FileChecks {
+		err := config.Validate()
+		if config.Base.OneOff {
+			return errors.New(oneOffErrorMsg)
+		}
+		if err != nil {
+			msg := fmt.Sprintf("Invalid healthcheck configuration: %s", err.Error())
+			return errors.New(msg)
+		}
+	}
+	for _, config := range p.ProcessChecks {
+		err := config.Validate()
+		if config.Base.OneOff {
+			return errors.New(oneOffErrorMsg)
+		}
+		if err != nil {
+			msg := fmt.Sprintf("Invalid healthcheck configuration: %s", err.Error())
+			return errors.New(msg)
+		}
+	}
+	for _, config := range p.HostChecks {
+		err := config.Validate()
+		if config.Base.OneOff {
+			return errors.New(oneOffErrorMsg)
+		}
+		if err != nil {
+			msg := fmt.Sprintf("Invalid healthcheck configuration: %s", err.Error())
+			return errors.New(msg)
+		}
+	}
+	for _, config := range p.PrometheusChecks {
+		err := config.Validate()
+		if config.Base.OneOff {
+			return errors.New(oneOffErrorMsg)
+		}
+		if err != nil {
+			msg := fmt.Sprintf("Invalid healthcheck configuration: %s", err.Error())
+			return errors.New(msg)
+		}
+	}
+	for _, config := range p.WebSocketChecks {
+		err := config.Validate()
+		if config.Base.OneOff {
+			return errors.New(oneOffErrorMsg)
+		}
+		if err != nil {
+			msg := fmt.Sprintf("Invalid healthcheck configuration: %s", err.Error())
+			return errors.New(msg)
+		}
+	}
+	for _, config := range p.MQTTChecks {
+		err := config.Validate()
+		if config.Base.OneOff {
+			return errors.New(oneOffErrorMsg)
+		}
+		if err != nil {
+			msg := fmt.Sprintf("Invalid healthcheck configuration: %s", err.Error())
+			return errors.New(msg)
+		}
+	}
+	for _, config := range p.LDAPChecks {
+		err := config.Validate()
+		if config.Base.OneOff {
+			return errors.New(oneOffErrorMsg)
+		}
+		if err != nil {
+			msg := fmt.Sprintf("Invalid healthcheck configuration: %s", err.Error())
+			return errors.New(msg)
+		}
+	}
+	for _, config := range p.SSHChecks {
+		err := config.Validate()
+		if config.Base.OneOff {
+			return errors.New(oneOffErrorMsg)
+		}
+		if err != nil {
+			msg := fmt.Sprintf("Invalid healthcheck configuration: %s", err.Error())
+			return errors.New(msg)
+		}
+	}
+	for _, config := range p.NTPChecks {
+		err := config.Validate()
+		if config.Base.OneOff {
+			return errors.New(oneOffErrorMsg)
+		}
+		if err != nil {
+			msg := fmt.Sprintf("Invalid healthcheck configuration: %s", err.Error())
+			return errors.New(msg)
+		}
+	}
+	for _, config := range p.SNMPChecks {
+		err := config.Validate()
+		if config.Base.OneOff {
+			return errors.New(oneOffErrorMsg)
+		}
+		if err != nil {
+			msg := fmt.Sprintf("Invalid healthcheck configuration: %s", err.Error())
+			return errors.New(msg)
+		}
+	}
+	for _, config := range p.DockerChecks {
+		err := config.Validate()
+		if config.Base.OneOff {
+			return errors.New(oneOffErrorMsg)
+		}
+		if err != nil {
+			msg := fmt.Sprintf("Invalid healthcheck configuration: %s", err.Error())
+			return errors.New(msg)
+		}
+	}
 	return nil
 }
@@ -2,6 +2,7 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"crypto/subtle"
 	"embed"
 	"fmt"
@@ -25,7 +26,13 @@ type ListResultsOutput struct {
 }
 
 type ListHealthchecksOutput struct {
-	Result []healthcheck.Healthcheck `json:"result"`
+	Result []healthcheck.Healthcheck            `json:"result"`
+	State  map[string]*healthcheck.RuntimeState `json:"state"`
+}
+
+type ListSilencesOutput struct {
+	Result    []healthcheck.Silence          `json:"result"`
+	Recurring []healthcheck.RecurringSilence `json:"recurring"`
 }
 
 // BasicResponse a type for HTTP responses
@@ -53,20 +60,22 @@ func (c *Component) addCheck(ec echo.Context, check healthcheck.Healthcheck) err
 var embededFiles embed.FS
 
 // oneOff executes an one-off healthcheck and returns its result
-func (c *Component) oneOff(ec echo.Context, healthcheck healthcheck.Healthcheck) error {
-	c.Logger.Info(fmt.Sprintf("Executing one-off healthcheck %s", healthcheck.Base().Name))
-	err := healthcheck.Initialize()
+func (c *Component) oneOff(ec echo.Context, check healthcheck.Healthcheck) error {
+	c.Logger.Info(fmt.Sprintf("Executing one-off healthcheck %s", check.Base().Name))
+	err := check.Initialize()
 	if err != nil {
-		msg := fmt.Sprintf("Fail to initialize one off healthcheck %s: %s", healthcheck.Base().Name, err.Error())
+		msg := fmt.Sprintf("Fail to initialize one off healthcheck %s: %s", check.Base().Name, err.Error())
 		return corbierror.New(msg, corbierror.Internal, true)
 	}
-	err = healthcheck.Execute()
+	ctx, cancel := context.WithTimeout(ec.Request().Context(), check.Timeout())
+	defer cancel()
+	_, err = healthcheck.Execute(ctx, check)
 	if err != nil {
-		msg := fmt.Sprintf("Execution of one off healthcheck %s failed: %s", healthcheck.Base().Name, err.Error())
+		msg := fmt.Sprintf("Execution of one off healthcheck %s failed: %s", check.Base().Name, err.Error())
 		c.Logger.Error(msg)
 		return corbierror.New(msg, corbierror.Internal, true)
 	}
-	msg := fmt.Sprintf("One-off healthcheck %s successfully executed", healthcheck.Base().Name)
+	msg := fmt.Sprintf("One-off healthcheck %s successfully executed", check.Base().Name)
 	c.Logger.Info(msg)
 	return ec.JSON(http.StatusCreated, newResponse(msg))
 }
@@ -186,6 +195,171 @@ func (c *Component) handlers() {
 			return c.handleCheck(ec, healthcheck)
 		})
 
+		apiGroup.POST("/healthcheck/file", func(ec echo.Context) error {
+			var config healthcheck.FileHealthcheckConfiguration
+			if err := ec.Bind(&config); err != nil {
+				msg := fmt.Sprintf("Fail to create the file healthcheck. Invalid JSON: %s", err.Error())
+				return corbierror.New(msg, corbierror.BadRequest, true)
+			}
+			err := config.Validate()
+			if err != nil {
+				msg := fmt.Sprintf("Invalid healthcheck configuration: %s", err.Error())
+				return corbierror.New(msg, corbierror.BadRequest, true)
+			}
+			healthcheck := healthcheck.NewFileHealthcheck(c.Logger, &config)
+			return c.handleCheck(ec, healthcheck)
+		})
+
+		apiGroup.POST("/healthcheck/process", func(ec echo.Context) error {
+			var config healthcheck.ProcessHealthcheckConfiguration
+			if err := ec.Bind(&config); err != nil {
+				msg := fmt.Sprintf("Fail to create the process healthcheck. Invalid JSON: %s", err.Error())
+				return corbierror.New(msg, corbierror.BadRequest, true)
+			}
+			err := config.Validate()
+			if err != nil {
+				msg := fmt.Sprintf("Invalid healthcheck configuration: %s", err.Error())
+				return corbierror.New(msg, corbierror.BadRequest, true)
+			}
+			healthcheck := healthcheck.NewProcessHealthcheck(c.Logger, &config)
+			return c.handleCheck(ec, healthcheck)
+		})
+
+		apiGroup.POST("/healthcheck/host", func(ec echo.Context) error {
+			var config healthcheck.HostHealthcheckConfiguration
+			if err := ec.Bind(&config); err != nil {
+				msg := fmt.Sprintf("Fail to create the host healthcheck. Invalid JSON: %s", err.Error())
+				return corbierror.New(msg, corbierror.BadRequest, true)
+			}
+			err := config.Validate()
+			if err != nil {
+				msg := fmt.Sprintf("Invalid healthcheck configuration: %s", err.Error())
+				return corbierror.New(msg, corbierror.BadRequest, true)
+			}
+			healthcheck := healthcheck.NewHostHealthcheck(c.Logger, &config)
+			return c.handleCheck(ec, healthcheck)
+		})
+
+		apiGroup.POST("/healthcheck/prometheus", func(ec echo.Context) error {
+			var config healthcheck.PrometheusHealthcheckConfiguration
+			if err := ec.Bind(&config); err != nil {
+				msg := fmt.Sprintf("Fail to create the Prometheus healthcheck. Invalid JSON: %s", err.Error())
+				return corbierror.New(msg, corbierror.BadRequest, true)
+			}
+			err := config.Validate()
+			if err != nil {
+				msg := fmt.Sprintf("Invalid healthcheck configuration: %s", err.Error())
+				return corbierror.New(msg, corbierror.BadRequest, true)
+			}
+			healthcheck := healthcheck.NewPrometheusHealthcheck(c.Logger, &config)
+			return c.handleCheck(ec, healthcheck)
+		})
+
+		apiGroup.POST("/healthcheck/websocket", func(ec echo.Context) error {
+			var config healthcheck.WebSocketHealthcheckConfiguration
+			if err := ec.Bind(&config); err != nil {
+				msg := fmt.Sprintf("Fail to create the WebSocket healthcheck. Invalid JSON: %s", err.Error())
+				return corbierror.New(msg, corbierror.BadRequest, true)
+			}
+			err := config.Validate()
+			if err != nil {
+				msg := fmt.Sprintf("Invalid healthcheck configuration: %s", err.Error())
+				return corbierror.New(msg, corbierror.BadRequest, true)
+			}
+			healthcheck := healthcheck.NewWebSocketHealthcheck(c.Logger, &config)
+			return c.handleCheck(ec, healthcheck)
+		})
+
+		apiGroup.POST("/healthcheck/mqtt", func(ec echo.Context) error {
+			var config healthcheck.MQTTHealthcheckConfiguration
+			if err := ec.Bind(&config); err != nil {
+				msg := fmt.Sprintf("Fail to create the MQTT healthcheck. Invalid JSON: %s", err.Error())
+				return corbierror.New(msg, corbierror.BadRequest, true)
+			}
+			err := config.Validate()
+			if err != nil {
+				msg := fmt.Sprintf("Invalid healthcheck configuration: %s", err.Error())
+				return corbierror.New(msg, corbierror.BadRequest, true)
+			}
+			healthcheck := healthcheck.NewMQTTHealthcheck(c.Logger, &config)
+			return c.handleCheck(ec, healthcheck)
+		})
+
+		apiGroup.POST("/healthcheck/ldap", func(ec echo.Context) error {
+			var config healthcheck.LDAPHealthcheckConfiguration
+			if err := ec.Bind(&config); err != nil {
+				msg := fmt.Sprintf("Fail to create the LDAP healthcheck. Invalid JSON: %s", err.Error())
+				return corbierror.New(msg, corbierror.BadRequest, true)
+			}
+			err := config.Validate()
+			if err != nil {
+				msg := fmt.Sprintf("Invalid healthcheck configuration: %s", err.Error())
+				return corbierror.New(msg, corbierror.BadRequest, true)
+			}
+			healthcheck := healthcheck.NewLDAPHealthcheck(c.Logger, &config)
+			return c.handleCheck(ec, healthcheck)
+		})
+
+		apiGroup.POST("/healthcheck/ssh", func(ec echo.Context) error {
+			var config healthcheck.SSHHealthcheckConfiguration
+			if err := ec.Bind(&config); err != nil {
+				msg := fmt.Sprintf("Fail to create the SSH healthcheck. Invalid JSON: %s", err.Error())
+				return corbierror.New(msg, corbierror.BadRequest, true)
+			}
+			err := config.Validate()
+			if err != nil {
+				msg := fmt.Sprintf("Invalid healthcheck configuration: %s", err.Error())
+				return corbierror.New(msg, corbierror.BadRequest, true)
+			}
+			healthcheck := healthcheck.NewSSHHealthcheck(c.Logger, &config)
+			return c.handleCheck(ec, healthcheck)
+		})
+
+		apiGroup.POST("/healthcheck/ntp", func(ec echo.Context) error {
+			var config healthcheck.NTPHealthcheckConfiguration
+			if err := ec.Bind(&config); err != nil {
+				msg := fmt.Sprintf("Fail to create the NTP healthcheck. Invalid JSON: %s", err.Error())
+				return corbierror.New(msg, corbierror.BadRequest, true)
+			}
+			err := config.Validate()
+			if err != nil {
+				msg := fmt.Sprintf("Invalid healthcheck configuration: %s", err.Error())
+				return corbierror.New(msg, corbierror.BadRequest, true)
+			}
+			healthcheck := healthcheck.NewNTPHealthcheck(c.Logger, &config)
+			return c.handleCheck(ec, healthcheck)
+		})
+
+		apiGroup.POST("/healthcheck/snmp", func(ec echo.Context) error {
+			var config healthcheck.SNMPHealthcheckConfiguration
+			if err := ec.Bind(&config); err != nil {
+				msg := fmt.Sprintf("Fail to create the SNMP healthcheck. Invalid JSON: %s", err.Error())
+				return corbierror.New(msg, corbierror.BadRequest, true)
+			}
+			err := config.Validate()
+			if err != nil {
+				msg := fmt.Sprintf("Invalid healthcheck configuration: %s", err.Error())
+				return corbierror.New(msg, corbierror.BadRequest, true)
+			}
+			healthcheck := healthcheck.NewSNMPHealthcheck(c.Logger, &config)
+			return c.handleCheck(ec, healthcheck)
+		})
+
+		apiGroup.POST("/healthcheck/docker", func(ec echo.Context) error {
+			var config healthcheck.DockerHealthcheckConfiguration
+			if err := ec.Bind(&config); err != nil {
+				msg := fmt.Sprintf("Fail to create the Docker healthcheck. Invalid JSON: %s", err.Error())
+				return corbierror.New(msg, corbierror.BadRequest, true)
+			}
+			err := config.Validate()
+			if err != nil {
+				msg := fmt.Sprintf("Invalid healthcheck configuration: %s", err.Error())
+				return corbierror.New(msg, corbierror.BadRequest, true)
+			}
+			healthcheck := healthcheck.NewDockerHealthcheck(c.Logger, &config)
+			return c.handleCheck(ec, healthcheck)
+		})
+
 		apiGroup.POST("/healthcheck/bulk", func(ec echo.Context) error {
 			bulkLock.Lock()
 			defer bulkLock.Unlock()
@@ -246,6 +420,105 @@ func (c *Component) handlers() {
 				}
 				newChecks[config.Base.Name] = true
 			}
+			for i := range payload.FileChecks {
+				config := payload.FileChecks[i]
+				healthcheck := healthcheck.NewFileHealthcheck(c.Logger, &config)
+				err := c.addCheck(ec, healthcheck)
+				if err != nil {
+					return c.addCheckError(ec, healthcheck, err)
+				}
+				newChecks[config.Base.Name] = true
+			}
+			for i := range payload.ProcessChecks {
+				config := payload.ProcessChecks[i]
+				healthcheck := healthcheck.NewProcessHealthcheck(c.Logger, &config)
+				err := c.addCheck(ec, healthcheck)
+				if err != nil {
+					return c.addCheckError(ec, healthcheck, err)
+				}
+				newChecks[config.Base.Name] = true
+			}
+			for i := range payload.HostChecks {
+				config := payload.HostChecks[i]
+				healthcheck := healthcheck.NewHostHealthcheck(c.Logger, &config)
+				err := c.addCheck(ec, healthcheck)
+				if err != nil {
+					return c.addCheckError(ec, healthcheck, err)
+				}
+				newChecks[config.Base.Name] = true
+			}
+			for i := range payload.PrometheusChecks {
+				config := payload.PrometheusChecks[i]
+				healthcheck := healthcheck.NewPrometheusHealthcheck(c.Logger, &config)
+				err := c.addCheck(ec, healthcheck)
+				if err != nil {
+					return c.addCheckError(ec, healthcheck, err)
+				}
+				newChecks[config.Base.Name] = true
+			}
+			for i := range payload.WebSocketChecks {
+				config := payload.WebSocketChecks[i]
+				healthcheck := healthcheck.NewWebSocketHealthcheck(c.Logger, &config)
+				err := c.addCheck(ec, healthcheck)
+				if err != nil {
+					return c.addCheckError(ec, healthcheck, err)
+				}
+				newChecks[config.Base.Name] = true
+			}
+			for i := range payload.MQTTChecks {
+				config := payload.MQTTChecks[i]
+				healthcheck := healthcheck.NewMQTTHealthcheck(c.Logger, &config)
+				err := c.addCheck(ec, healthcheck)
+				if err != nil {
+					return c.addCheckError(ec, healthcheck, err)
+				}
+				newChecks[config.Base.Name] = true
+			}
+			for i := range payload.LDAPChecks {
+				config := payload.LDAPChecks[i]
+				healthcheck := healthcheck.NewLDAPHealthcheck(c.Logger, &config)
+				err := c.addCheck(ec, healthcheck)
+				if err != nil {
+					return c.addCheckError(ec, healthcheck, err)
+				}
+				newChecks[config.Base.Name] = true
+			}
+			for i := range payload.SSHChecks {
+				config := payload.SSHChecks[i]
+				healthcheck := healthcheck.NewSSHHealthcheck(c.Logger, &config)
+				err := c.addCheck(ec, healthcheck)
+				if err != nil {
+					return c.addCheckError(ec, healthcheck, err)
+				}
+				newChecks[config.Base.Name] = true
+			}
+			for i := range payload.NTPChecks {
+				config := payload.NTPChecks[i]
+				healthcheck := healthcheck.NewNTPHealthcheck(c.Logger, &config)
+				err := c.addCheck(ec, healthcheck)
+				if err != nil {
+					return c.addCheckError(ec, healthcheck, err)
+				}
+				newChecks[config.Base.Name] = true
+			}
+			for i := range payload.SNMPChecks {
+				config := payload.SNMPChecks[i]
+				healthcheck := healthcheck.NewSNMPHealthcheck(c.Logger, &config)
+				err := c.addCheck(ec, healthcheck)
+				if err != nil {
+					return c.addCheckError(ec, healthcheck, err)
+				}
+				newChecks[config.Base.Name] = true
+			}
+			for i := range payload.DockerChecks {
+				config := payload.DockerChecks[i]
+				healthcheck := healthcheck.NewDockerHealthcheck(c.Logger, &config)
+				err := c.addCheck(ec, healthcheck)
+				if err != nil {
+					return c.addCheckError(ec, healthcheck, err)
+				}
+				newChecks[config.Base.Name] = true
+			}
 			err = c.healthcheck.RemoveNonConfiguredHealthchecks(oldChecks, newChecks)
 			if err != nil {
 				return corbierror.Wrap(err, "Internal error", corbierror.Internal, true)
@@ -256,6 +529,7 @@ func (c *Component) handlers() {
 		apiGroup.GET("/healthcheck", func(ec echo.Context) error {
 			return ec.JSON(http.StatusOK, ListHealthchecksOutput{
 				Result: c.healthcheck.ListChecks(),
+				State:  c.healthcheck.RuntimeStates(),
 			})
 		})
 		apiGroup.GET("/healthcheck/:name", func(ec echo.Context) error {
@@ -277,6 +551,98 @@ func (c *Component) handlers() {
 			}
 			return ec.JSON(http.StatusOK, newResponse(fmt.Sprintf("Successfully deleted healthcheck %s", name)))
 		})
+
+		apiGroup.POST("/healthcheck/run", func(ec echo.Context) error {
+			var payload RunPayload
+			if err := ec.Bind(&payload); err != nil {
+				msg := fmt.Sprintf("Fail to run the healthchecks. Invalid JSON: %s", err.Error())
+				return corbierror.New(msg, corbierror.BadRequest, true)
+			}
+			err := payload.Validate()
+			if err != nil {
+				msg := fmt.Sprintf("Fail to run the healthchecks: %s", err.Error())
+				return corbierror.New(msg, corbierror.BadRequest, true)
+			}
+			c.Logger.Info(fmt.Sprintf("Executing healthchecks matching labels %v", payload.Labels))
+			results := c.healthcheck.RunChecks(payload.Labels)
+			output := ListResultsOutput{
+				Result: make([]healthcheck.Result, 0, len(results)),
+			}
+			for _, result := range results {
+				output.Result = append(output.Result, *result)
+			}
+			return ec.JSON(http.StatusOK, output)
+		})
+
+		apiGroup.POST("/healthcheck/:name/run", func(ec echo.Context) error {
+			name := ec.Param("name")
+			c.Logger.Info(fmt.Sprintf("Executing healthcheck %s", name))
+			result, err := c.healthcheck.RunCheck(name)
+			if err != nil {
+				return corbierror.New(err.Error(), corbierror.NotFound, true)
+			}
+			return ec.JSON(http.StatusOK, result)
+		})
+
+		apiGroup.POST("/healthcheck/:name/pause", func(ec echo.Context) error {
+			name := ec.Param("name")
+			if c.healthcheck.GetCheck(name) == nil {
+				return corbierror.New("Healthcheck not found", corbierror.NotFound, true)
+			}
+			c.Logger.Info(fmt.Sprintf("Pausing healthcheck %s", name))
+			err := c.healthcheck.PauseCheck(name)
+			if err != nil {
+				msg := fmt.Sprintf("Fail to pause the healthcheck: %s", err.Error())
+				return corbierror.New(msg, corbierror.Internal, true)
+			}
+			return ec.JSON(http.StatusOK, newResponse(fmt.Sprintf("Successfully paused healthcheck %s", name)))
+		})
+
+		apiGroup.POST("/healthcheck/:name/resume", func(ec echo.Context) error {
+			name := ec.Param("name")
+			if c.healthcheck.GetCheck(name) == nil {
+				return corbierror.New("Healthcheck not found", corbierror.NotFound, true)
+			}
+			c.Logger.Info(fmt.Sprintf("Resuming healthcheck %s", name))
+			err := c.healthcheck.ResumeCheck(name)
+			if err != nil {
+				msg := fmt.Sprintf("Fail to resume the healthcheck: %s", err.Error())
+				return corbierror.New(msg, corbierror.Internal, true)
+			}
+			return ec.JSON(http.StatusOK, newResponse(fmt.Sprintf("Successfully resumed healthcheck %s", name)))
+		})
+
+		apiGroup.POST("/silence", func(ec echo.Context) error {
+			var silence healthcheck.Silence
+			if err := ec.Bind(&silence); err != nil {
+				msg := fmt.Sprintf("Fail to create the silence. Invalid JSON: %s", err.Error())
+				return corbierror.New(msg, corbierror.BadRequest, true)
+			}
+			result, err := c.healthcheck.Silences.Add(silence)
+			if err != nil {
+				msg := fmt.Sprintf("Invalid silence configuration: %s", err.Error())
+				return corbierror.New(msg, corbierror.BadRequest, true)
+			}
+			c.Logger.Info(fmt.Sprintf("Silence %s created", result.ID))
+			return ec.JSON(http.StatusCreated, result)
+		})
+
+		apiGroup.GET("/silence", func(ec echo.Context) error {
+			return ec.JSON(http.StatusOK, ListSilencesOutput{
+				Result:    c.healthcheck.Silences.List(),
+				Recurring: c.healthcheck.Silences.Recurring(),
+			})
+		})
+
+		apiGroup.DELETE("/silence/:id", func(ec echo.Context) error {
+			id := ec.Param("id")
+			c.Logger.Info(fmt.Sprintf("Deleting silence %s", id))
+			err := c.healthcheck.Silences.Delete(id)
+			if err != nil {
+				return corbierror.New(err.Error(), corbierror.NotFound, true)
+			}
+			return ec.JSON(http.StatusOK, newResponse(fmt.Sprintf("Successfully deleted silence %s", id)))
+		})
 	}
 
 	if !c.Config.DisableResultAPI {
@@ -321,8 +687,13 @@ func (c *Component) handlers() {
 				return corbierror.Wrap(err, "Internal error", corbierror.Internal, true)
 			}
 			if path == "index.html" {
+				states := c.healthcheck.RuntimeStates()
 				tmpl := template.New("frontend")
 				tmpl.Funcs(template.FuncMap{
+					"paused": func(name string) bool {
+						state, ok := states[name]
+						return ok && state.Paused
+					},
 					"last": func(x int, a interface{}) bool {
 						return x == reflect.ValueOf(a).Len()-1
 					},
@@ -3,6 +3,7 @@ package http
 import (
 	"bytes"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -10,6 +11,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"go.uber.org/zap"
 
@@ -57,6 +59,10 @@ func TestHandlers(t *testing.T) {
 			endpoint: "/api/v1/healthcheck/tls",
 			payload:  `{"name":"tls-check","description":"bar","interval":"10m","one-off":false,"target":"mcorbin.fr","port":9999,"timeout":"10s"}`,
 		},
+		{
+			endpoint: "/api/v1/healthcheck/file",
+			payload:  `{"name":"file-check","description":"bar","interval":"10m","one-off":false,"path":"/tmp/*.lock","absent":true,"timeout":"10s"}`,
+		},
 	}
 	client := &http.Client{}
 	for _, c := range cases {
@@ -73,7 +79,7 @@ func TestHandlers(t *testing.T) {
 			t.Fatalf("HTTP request failed, status %d", resp.StatusCode)
 		}
 	}
-	if len(healthcheck.Healthchecks) != 4 {
+	if len(healthcheck.Healthchecks) != 5 {
 		t.Fatalf("Healthchecks were not successfully created: %d", len(healthcheck.Healthchecks))
 	}
 
@@ -129,7 +135,7 @@ func TestHandlers(t *testing.T) {
 		t.Fatalf("Invalid body\n")
 	}
 	// delete everything
-	checks := []string{"foo", "bar", "baz", "tls-check"}
+	checks := []string{"foo", "bar", "baz", "tls-check", "file-check"}
 	for _, c := range checks {
 		req, err := http.NewRequest("DELETE", fmt.Sprintf("http://127.0.0.1:2001/api/v1/healthcheck/%s", c), nil)
 		if err != nil {
@@ -341,3 +347,270 @@ func TestBasicAuth(t *testing.T) {
 		t.Fatalf("Expected 200, got status %d", resp.StatusCode)
 	}
 }
+
+func TestSilenceEndpoints(t *testing.T) {
+	logger := zap.NewExample()
+	prom, err := prometheus.New()
+	if err != nil {
+		t.Fatalf("Error creating prometheus component :\n%v", err)
+	}
+	checkComponent, err := healthcheck.New(logger, make(chan *healthcheck.Result, 10), prom, []string{})
+	if err != nil {
+		t.Fatalf("Fail to create the healthcheck component\n%v", err)
+	}
+	component, err := New(logger, memorystore.NewMemoryStore(logger), prom, &Configuration{Host: "127.0.0.1", Port: 2003}, checkComponent)
+	if err != nil {
+		t.Fatalf("Fail to create the component\n%v", err)
+	}
+	err = component.Start()
+	if err != nil {
+		t.Fatalf("Fail to start the component\n%v", err)
+	}
+	client := &http.Client{}
+	reqBody := `{"labels":{"env":"prod"},"end":"2100-01-01T00:00:00Z","comment":"maintenance"}`
+	resp, err := client.Post("http://127.0.0.1:2003/api/v1/silence", "application/json", bytes.NewBuffer([]byte(reqBody)))
+	if err != nil {
+		t.Fatalf("HTTP request failed\n%v", err)
+	}
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("HTTP request failed, status %d", resp.StatusCode)
+	}
+	var silence healthcheck.Silence
+	err = json.NewDecoder(resp.Body).Decode(&silence)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("Fail to read the body\n%v", err)
+	}
+	if silence.ID == "" || silence.Comment != "maintenance" {
+		t.Fatalf("Invalid silence %v", silence)
+	}
+	if !checkComponent.Silences.Silenced("foo", map[string]string{"env": "prod"}, time.Now()) {
+		t.Fatalf("The healthcheck should be silenced")
+	}
+	resp, err = client.Post("http://127.0.0.1:2003/api/v1/silence", "application/json", bytes.NewBuffer([]byte(`{"end":"2100-01-01T00:00:00Z"}`)))
+	if err != nil {
+		t.Fatalf("HTTP request failed\n%v", err)
+	}
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected 400, got status %d", resp.StatusCode)
+	}
+	resp, err = client.Get("http://127.0.0.1:2003/api/v1/silence")
+	if err != nil {
+		t.Fatalf("HTTP request failed\n%v", err)
+	}
+	var list ListSilencesOutput
+	err = json.NewDecoder(resp.Body).Decode(&list)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("Fail to read the body\n%v", err)
+	}
+	if len(list.Result) != 1 || list.Result[0].ID != silence.ID {
+		t.Fatalf("Invalid silences %v", list)
+	}
+	req, err := http.NewRequest("DELETE", "http://127.0.0.1:2003/api/v1/silence/"+silence.ID, nil)
+	if err != nil {
+		t.Fatalf("Fail to build the HTTP request\n%v", err)
+	}
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatalf("HTTP request failed\n%v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("HTTP request failed, status %d", resp.StatusCode)
+	}
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatalf("HTTP request failed\n%v", err)
+	}
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("Expected 404, got status %d", resp.StatusCode)
+	}
+	if len(checkComponent.Silences.List()) != 0 {
+		t.Fatalf("The silence was not deleted")
+	}
+	err = component.Stop()
+	if err != nil {
+		t.Fatalf("Fail to stop the component\n%v", err)
+	}
+}
+
+func TestPauseResumeEndpoints(t *testing.T) {
+	logger := zap.NewExample()
+	prom, err := prometheus.New()
+	if err != nil {
+		t.Fatalf("Error creating prometheus component :\n%v", err)
+	}
+	checkComponent, err := healthcheck.New(logger, make(chan *healthcheck.Result, 10), prom, []string{})
+	if err != nil {
+		t.Fatalf("Fail to create the healthcheck component\n%v", err)
+	}
+	component, err := New(logger, memorystore.NewMemoryStore(logger), prom, &Configuration{Host: "127.0.0.1", Port: 2004}, checkComponent)
+	if err != nil {
+		t.Fatalf("Fail to create the component\n%v", err)
+	}
+	err = component.Start()
+	if err != nil {
+		t.Fatalf("Fail to start the component\n%v", err)
+	}
+	err = checkComponent.AddCheck(healthcheck.NewCommandHealthcheck(
+		logger,
+		&healthcheck.CommandHealthcheckConfiguration{
+			Base: healthcheck.Base{
+				Name:     "foo",
+				Interval: healthcheck.Duration(time.Second * 5),
+			},
+			Command: "true",
+			Timeout: healthcheck.Duration(time.Second * 2),
+		}))
+	if err != nil {
+		t.Fatalf("Fail to add the healthcheck\n%v", err)
+	}
+	client := &http.Client{}
+	cases := []struct {
+		path   string
+		status int
+		paused bool
+	}{
+		{path: "/api/v1/healthcheck/bar/pause", status: http.StatusNotFound},
+		{path: "/api/v1/healthcheck/foo/pause", status: http.StatusOK, paused: true},
+		{path: "/api/v1/healthcheck/foo/resume", status: http.StatusOK, paused: false},
+	}
+	for _, c := range cases {
+		resp, err := client.Post("http://127.0.0.1:2004"+c.path, "application/json", nil)
+		if err != nil {
+			t.Fatalf("HTTP request failed\n%v", err)
+		}
+		if resp.StatusCode != c.status {
+			t.Fatalf("Expected %d, got status %d for %s", c.status, resp.StatusCode, c.path)
+		}
+		if checkComponent.RuntimeStates()["foo"].Paused != c.paused {
+			t.Fatalf("Invalid paused state for %s", c.path)
+		}
+	}
+	err = checkComponent.PauseCheck("foo")
+	if err != nil {
+		t.Fatalf("Fail to pause the healthcheck\n%v", err)
+	}
+	resp, err := client.Get("http://127.0.0.1:2004/api/v1/healthcheck")
+	if err != nil {
+		t.Fatalf("HTTP request failed\n%v", err)
+	}
+	bodyBytes, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("Fail to read the body\n%v", err)
+	}
+	if !strings.Contains(string(bodyBytes), `"paused":true`) {
+		t.Fatalf("The healthcheck should be marked as paused: %s", string(bodyBytes))
+	}
+	err = component.Stop()
+	if err != nil {
+		t.Fatalf("Fail to stop the component\n%v", err)
+	}
+	err = checkComponent.Stop()
+	if err != nil {
+		t.Fatalf("Fail to stop the healthcheck component\n%v", err)
+	}
+}
+
+func TestRunEndpoints(t *testing.T) {
+	logger := zap.NewExample()
+	prom, err := prometheus.New()
+	if err != nil {
+		t.Fatalf("Error creating prometheus component :\n%v", err)
+	}
+	checkComponent, err := healthcheck.New(logger, make(chan *healthcheck.Result, 10), prom, []string{})
+	if err != nil {
+		t.Fatalf("Fail to create the healthcheck component\n%v", err)
+	}
+	component, err := New(logger, memorystore.NewMemoryStore(logger), prom, &Configuration{Host: "127.0.0.1", Port: 2005}, checkComponent)
+	if err != nil {
+		t.Fatalf("Fail to create the component\n%v", err)
+	}
+	err = component.Start()
+	if err != nil {
+		t.Fatalf("Fail to start the component\n%v", err)
+	}
+	err = checkComponent.AddCheck(healthcheck.NewCommandHealthcheck(
+		logger,
+		&healthcheck.CommandHealthcheckConfiguration{
+			Base: healthcheck.Base{
+				Name:     "foo",
+				Schedule: "0 0 1 1 *",
+				Labels:   map[string]string{"env": "prod"},
+			},
+			Command: "true",
+			Timeout: healthcheck.Duration(time.Second * 2),
+		}))
+	if err != nil {
+		t.Fatalf("Fail to add the healthcheck\n%v", err)
+	}
+	client := &http.Client{}
+	resp, err := client.Post("http://127.0.0.1:2005/api/v1/healthcheck/bar/run", "application/json", nil)
+	if err != nil {
+		t.Fatalf("HTTP request failed\n%v", err)
+	}
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("Expected 404, got status %d", resp.StatusCode)
+	}
+	resp, err = client.Post("http://127.0.0.1:2005/api/v1/healthcheck/foo/run", "application/json", nil)
+	if err != nil {
+		t.Fatalf("HTTP request failed\n%v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("HTTP request failed, status %d", resp.StatusCode)
+	}
+	var result healthcheck.Result
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("Fail to read the body\n%v", err)
+	}
+	if result.Name != "foo" || !result.Success {
+		t.Fatalf("Invalid result %v", result)
+	}
+	cases := []struct {
+		body  string
+		count int
+	}{
+		{body: `{"labels":{"env":"prod"}}`, count: 1},
+		{body: `{"labels":{"env":"dev"}}`, count: 0},
+	}
+	for _, c := range cases {
+		resp, err = client.Post("http://127.0.0.1:2005/api/v1/healthcheck/run", "application/json", bytes.NewBuffer([]byte(c.body)))
+		if err != nil {
+			t.Fatalf("HTTP request failed\n%v", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("HTTP request failed, status %d", resp.StatusCode)
+		}
+		var list ListResultsOutput
+		err = json.NewDecoder(resp.Body).Decode(&list)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("Fail to read the body\n%v", err)
+		}
+		if len(list.Result) != c.count {
+			t.Fatalf("Invalid results for %s: %v", c.body, list)
+		}
+	}
+	// a selector is required
+	for _, body := range []string{`{}`, `{"labels":{}}`} {
+		resp, err = client.Post("http://127.0.0.1:2005/api/v1/healthcheck/run", "application/json", bytes.NewBuffer([]byte(body)))
+		if err != nil {
+			t.Fatalf("HTTP request failed\n%v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("Invalid status %d for %s", resp.StatusCode, body)
+		}
+	}
+	err = component.Stop()
+	if err != nil {
+		t.Fatalf("Fail to stop the component\n%v", err)
+	}
+	err = checkComponent.Stop()
+	if err != nil {
+		t.Fatalf("Fail to stop the healthcheck component\n%v", err)
+	}
+}